@@ -0,0 +1,94 @@
+package main
+
+// This file contains the request IDs and the access logs.
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/riacataquian/news/internal/logging"
+	"github.com/riacataquian/news/internal/metrics"
+
+	"github.com/gorilla/mux"
+)
+
+// accessLog wraps h with a request-scoped logger:
+// it propagates the request's X-Request-ID header, or assigns it a new ID, echoing it in the response,
+// then injects a logger carrying the ID to the request's context, see logging.FromContext.
+//
+// Once served, it logs the request as a JSON line: its method, route template, status, latency,
+// bytes written, compressed if negotiated, see compress.Handler, the ID of its client key, if authenticated,
+// and the count of requests made to newsapi.
+// It also counts the request and its latency per route template, see metrics.HTTPRequests.
+func accessLog(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		id := logging.RequestID(r)
+		w.Header().Set(logging.RequestIDHeader, id)
+
+		l := logging.Default.With("requestId", id)
+		rec := &responseRecorder{ResponseWriter: w}
+		h.ServeHTTP(rec, r.WithContext(logging.NewContext(r.Context(), l)))
+
+		route, elapsed := routeTemplate(r), time.Since(start)
+		metrics.HTTPRequests.Inc(route, r.Method, strconv.Itoa(rec.status))
+		metrics.HTTPDuration.Observe(elapsed.Seconds(), route)
+
+		l.Log("request", logging.Fields{
+			"method":        r.Method,
+			"route":         route,
+			"path":          r.URL.Path,
+			"status":        rec.status,
+			"latencyMs":     float64(elapsed) / float64(time.Millisecond),
+			"bytes":         rec.bytes,
+			"upstreamCalls": l.UpstreamCalls(),
+		})
+	})
+}
+
+// routeTemplate returns the template of the route matching r, i.e., /api/cron/runs/{id},
+// so that requests to a route are grouped regardless of their variables.
+func routeTemplate(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return ""
+	}
+	tpl, err := route.GetPathTemplate()
+	if err != nil {
+		return ""
+	}
+	return tpl
+}
+
+// responseRecorder records the status code and the count of bytes written of a response.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+// WriteHeader implements http.ResponseWriter.
+func (rec *responseRecorder) WriteHeader(code int) {
+	if rec.status == 0 {
+		rec.status = code
+	}
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+// Write implements http.ResponseWriter.
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	n, err := rec.ResponseWriter.Write(b)
+	rec.bytes += n
+	return n, err
+}
+
+// Flush implements http.Flusher, so that compressed responses are streamed through the recorder.
+func (rec *responseRecorder) Flush() {
+	if f, ok := rec.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
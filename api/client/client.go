@@ -0,0 +1,153 @@
+// Package client is the Go client of the news server's API, for services calling it rather than newsapi directly.
+//
+// It wraps the /api/v1/list and /api/v1/headlines routes.
+// The parameters and errors are the server's own types, aliased here as their packages are internal.
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/riacataquian/news/api/news"
+	"github.com/riacataquian/news/internal/httperror"
+	"github.com/riacataquian/news/internal/newsclient/headlines"
+	"github.com/riacataquian/news/internal/newsclient/list"
+
+	"github.com/gorilla/schema"
+)
+
+// Prefix is the prefix of the version of the routes the client calls.
+const Prefix = "/api/v1"
+
+type (
+	// ListParams are the parameters of List, the ones of newsapi's everything endpoint.
+	ListParams = list.Params
+	// HeadlinesParams are the parameters of TopHeadlines, the ones of newsapi's top-headlines endpoint.
+	HeadlinesParams = headlines.Params
+	// Error is the error of unsuccessful responses, i.e., an invalid parameter or an unauthorized client key.
+	// Its Code is the response's status.
+	Error = httperror.HTTPError
+)
+
+// Response is a page of news articles, the SuccessResponse of the server whose data are the articles.
+type Response struct {
+	Code       int    `json:"code"`
+	RequestURL string `json:"requestURL"`
+	// Count is the count of Articles.
+	Count int `json:"count"`
+	// Page is the page of the Articles.
+	Page int `json:"page"`
+	// TotalCount is the count of the queryable articles.
+	TotalCount int          `json:"totalCount"`
+	Articles   []*news.News `json:"data"`
+	// Links are the links to the other pages, relative to the server.
+	Links *Links `json:"links,omitempty"`
+}
+
+// Links are the links to the pages of a Response. Prev and Next are empty on the first and the last page.
+type Links struct {
+	Self  string `json:"self,omitempty"`
+	First string `json:"first"`
+	Prev  string `json:"prev,omitempty"`
+	Next  string `json:"next,omitempty"`
+	Last  string `json:"last"`
+}
+
+// Client calls the API of a news server.
+type Client struct {
+	// BaseURL is the URL the server is served at, i.e., https://news.example.com.
+	BaseURL string
+	// Key is the client key requests are authenticated with, i.e., nk_..., see cmd/apikey.
+	Key string
+	// HTTPClient sends the requests, http.DefaultClient if nil.
+	HTTPClient *http.Client
+}
+
+// New returns a new Client of the server at baseURL, authenticated with the client key.
+func New(baseURL, key string) *Client {
+	return &Client{BaseURL: strings.TrimSuffix(baseURL, "/"), Key: key}
+}
+
+// List searches the news articles of newsapi's everything endpoint.
+func (c *Client) List(ctx context.Context, params *ListParams) (*Response, error) {
+	return c.get(ctx, "/list", params)
+}
+
+// TopHeadlines lists the news articles of newsapi's top-headlines endpoint.
+func (c *Client) TopHeadlines(ctx context.Context, params *HeadlinesParams) (*Response, error) {
+	return c.get(ctx, "/headlines", params)
+}
+
+// get requests the route at path with the query parameters of params, as per their `schema` struct tags.
+//
+// Unsuccessful responses are returned as an *Error; errors sending the request or decoding the response as is.
+func (c *Client) get(ctx context.Context, path string, params interface{}) (*Response, error) {
+	q := url.Values{}
+	if err := schema.NewEncoder().Encode(params, q); err != nil {
+		return nil, fmt.Errorf("error encoding params: %v", err)
+	}
+	// Unset parameters are left out, rather than sent empty.
+	for k, v := range q {
+		if len(v) == 1 && (v[0] == "" || v[0] == "0") {
+			q.Del(k)
+		}
+	}
+
+	u := c.BaseURL + Prefix + path
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.Key)
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, decodeError(res)
+	}
+
+	resp := new(Response)
+	if err := json.NewDecoder(res.Body).Decode(resp); err != nil {
+		return nil, fmt.Errorf("error decoding response: %v", err)
+	}
+	return resp, nil
+}
+
+// decodeError returns the *Error of the unsuccessful response res.
+// Bodies that aren't an HTTPError, i.e., of a proxy in front of the server, are kept as its message.
+func decodeError(res *http.Response) error {
+	b, err := ioutil.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("error reading response: %v", err)
+	}
+
+	e := new(Error)
+	if err := json.Unmarshal(b, e); err != nil || e.Message == "" {
+		e = &Error{Message: strings.TrimSpace(string(b))}
+		if e.Message == "" {
+			e.Message = http.StatusText(res.StatusCode)
+		}
+		e.RequestURL = res.Request.URL.RequestURI()
+	}
+	e.Code = res.StatusCode
+	return e
+}
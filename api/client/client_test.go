@@ -0,0 +1,221 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+	"github.com/riacataquian/news/api/news"
+	"github.com/riacataquian/news/internal/httperror"
+	"github.com/riacataquian/news/internal/newsclient/list"
+	"github.com/riacataquian/news/web/handler"
+)
+
+var (
+	fakeArticles = []*news.News{
+		{
+			Source:      &news.Source{ID: "some-id", Name: "some-name"},
+			Author:      "some-author",
+			Title:       "some-title",
+			URL:         "some-url",
+			PublishedAt: time.Date(2018, 7, 28, 14, 28, 41, 0, time.UTC),
+		},
+	}
+
+	// fakeResponse is a SuccessResponse as the server writes it.
+	fakeResponse = &handler.SuccessResponse{
+		Code:       http.StatusOK,
+		RequestURL: "/api/v1/list?page=2&query=golang",
+		Count:      1,
+		Page:       2,
+		TotalCount: 21,
+		Data:       fakeArticles,
+		Links: &handler.Links{
+			Self:  "/api/v1/list?page=2&query=golang",
+			First: "/api/v1/list?page=1&query=golang",
+			Prev:  "/api/v1/list?page=1&query=golang",
+			Last:  "/api/v1/list?page=2&query=golang",
+		},
+	}
+)
+
+// setupStubServer returns a server answering with code and body, recording the requests to reqs.
+func setupStubServer(t *testing.T, code int, body interface{}, reqs *[]*http.Request) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*reqs = append(*reqs, r)
+		w.Header().Set("content-type", "application/json")
+		w.WriteHeader(code)
+		switch v := body.(type) {
+		case string:
+			w.Write([]byte(v))
+		default:
+			json.NewEncoder(w).Encode(v)
+		}
+	}))
+}
+
+func TestList(t *testing.T) {
+	t.Parallel()
+
+	var reqs []*http.Request
+	server := setupStubServer(t, http.StatusOK, fakeResponse, &reqs)
+	defer server.Close()
+
+	params := &ListParams{Query: "golang", SortBy: list.Popularity, Page: 2}
+	got, err := New(server.URL+"/", "nk_some-key").List(context.Background(), params)
+	if err != nil {
+		t.Fatalf("List(_, %+v): want (_, nil), got (_, %v)", params, err)
+	}
+
+	want := &Response{
+		Code:       http.StatusOK,
+		RequestURL: "/api/v1/list?page=2&query=golang",
+		Count:      1,
+		Page:       2,
+		TotalCount: 21,
+		Articles:   fakeArticles,
+		Links: &Links{
+			Self:  "/api/v1/list?page=2&query=golang",
+			First: "/api/v1/list?page=1&query=golang",
+			Prev:  "/api/v1/list?page=1&query=golang",
+			Last:  "/api/v1/list?page=2&query=golang",
+		},
+	}
+	if diff := pretty.Compare(got, want); diff != "" {
+		t.Errorf("List(_, %+v) diff: (-got +want)\n%s", params, diff)
+	}
+
+	if len(reqs) != 1 {
+		t.Fatalf("List(_, %+v): want 1 request, got %d", params, len(reqs))
+	}
+	r := reqs[0]
+	if got, want := r.URL.RequestURI(), "/api/v1/list?page=2&query=golang&sortBy=popularity"; got != want {
+		t.Errorf("List(_, %+v): want request to %q, got %q", params, want, got)
+	}
+	if got, want := r.Header.Get("Authorization"), "Bearer nk_some-key"; got != want {
+		t.Errorf("List(_, %+v): want Authorization %q, got %q", params, want, got)
+	}
+}
+
+func TestTopHeadlines(t *testing.T) {
+	t.Parallel()
+
+	var reqs []*http.Request
+	server := setupStubServer(t, http.StatusOK, fakeResponse, &reqs)
+	defer server.Close()
+
+	params := &HeadlinesParams{Country: "ph", PageSize: 10}
+	got, err := New(server.URL, "nk_some-key").TopHeadlines(context.Background(), params)
+	if err != nil {
+		t.Fatalf("TopHeadlines(_, %+v): want (_, nil), got (_, %v)", params, err)
+	}
+	if diff := pretty.Compare(got.Articles, fakeArticles); diff != "" {
+		t.Errorf("TopHeadlines(_, %+v) diff: (-got +want)\n%s", params, diff)
+	}
+	if got, want := reqs[0].URL.RequestURI(), "/api/v1/headlines?country=ph&pageSize=10"; got != want {
+		t.Errorf("TopHeadlines(_, %+v): want request to %q, got %q", params, want, got)
+	}
+}
+
+func TestListErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc string
+		code int
+		body interface{}
+		want *Error
+	}{
+		{
+			desc: "returns the server's HTTPError",
+			code: http.StatusBadRequest,
+			body: &httperror.HTTPError{
+				Code:       http.StatusBadRequest,
+				Message:    "invalid parameters",
+				RequestURL: "/api/v1/list",
+				FieldErrors: []httperror.FieldErrors{
+					{
+						Message: "invalid parameters",
+						Errors:  []httperror.FieldErr{{Field: "query", Errors: []string{"one of query, sources or domains is required"}}},
+					},
+				},
+			},
+			want: &Error{
+				Code:       http.StatusBadRequest,
+				Message:    "invalid parameters",
+				RequestURL: "/api/v1/list",
+				FieldErrors: []httperror.FieldErrors{
+					{
+						Message: "invalid parameters",
+						Errors:  []httperror.FieldErr{{Field: "query", Errors: []string{"one of query, sources or domains is required"}}},
+					},
+				},
+			},
+		},
+		{
+			desc: "returns an Error with the body of responses that aren't an HTTPError",
+			code: http.StatusBadGateway,
+			body: "upstream unavailable\n",
+			want: &Error{
+				Code:       http.StatusBadGateway,
+				Message:    "upstream unavailable",
+				RequestURL: "/api/v1/list",
+			},
+		},
+		{
+			desc: "returns an Error with the status text of responses without body",
+			code: http.StatusServiceUnavailable,
+			body: "",
+			want: &Error{
+				Code:       http.StatusServiceUnavailable,
+				Message:    "Service Unavailable",
+				RequestURL: "/api/v1/list",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		var reqs []*http.Request
+		server := setupStubServer(t, test.code, test.body, &reqs)
+
+		got, err := New(server.URL, "nk_some-key").List(context.Background(), &ListParams{})
+		server.Close()
+
+		v, ok := err.(*Error)
+		if !ok {
+			t.Errorf("%s: List(_, _): want (nil, *Error), got (%v, %v)", test.desc, got, err)
+			continue
+		}
+		if diff := pretty.Compare(v, test.want); diff != "" {
+			t.Errorf("%s: List(_, _) diff: (-got +want)\n%s", test.desc, diff)
+		}
+	}
+}
+
+// TestResponse fails once Response drifts from the SuccessResponse the server writes.
+func TestResponse(t *testing.T) {
+	t.Parallel()
+
+	want, err := json.Marshal(fakeResponse)
+	if err != nil {
+		t.Fatalf("error marshalling SuccessResponse: %v", err)
+	}
+
+	resp := new(Response)
+	if err := json.Unmarshal(want, resp); err != nil {
+		t.Fatalf("error unmarshalling SuccessResponse: %v", err)
+	}
+	got, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatalf("error marshalling Response: %v", err)
+	}
+
+	if diff := pretty.Compare(string(got), string(want)); diff != "" {
+		t.Errorf("Response diff: (-got +want)\n%s", diff)
+	}
+}
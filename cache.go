@@ -0,0 +1,54 @@
+package main
+
+// This file contains the caching headers of the responses and the conditional requests.
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+
+	"github.com/riacataquian/news/internal/httpcache"
+	"github.com/riacataquian/news/internal/metrics"
+	"github.com/riacataquian/news/web/handler"
+)
+
+// writeCached writes resp to w with its caching headers: cacheControl, its strong ETag, computed over its body,
+// and its Last-Modified date, if any.
+//
+// It answers 304 Not Modified without body when the client's copy is fresh as per the If-None-Match
+// or If-Modified-Since headers of r, see httpcache.NotModified, counting the hits of the conditional requests,
+// see metrics.CacheLookups. Responses with status codes other than 200 aren't cached.
+func writeCached(ctx context.Context, w http.ResponseWriter, r *http.Request, cacheControl string, resp *handler.SuccessResponse) {
+	if resp.Code != http.StatusOK {
+		w.Header().Set("cache-control", handler.NoStore)
+		w.WriteHeader(resp.Code)
+		encode(ctx, w, resp)
+		return
+	}
+
+	var body bytes.Buffer
+	encode(ctx, &body, resp)
+	etag := httpcache.ETag(body.Bytes())
+
+	h := w.Header()
+	h.Set("cache-control", cacheControl)
+	h.Set("etag", etag)
+	// Responses differ per client key, shared caches mustn't serve them to another one.
+	h.Add("vary", "Authorization")
+	if !resp.LastModified.IsZero() {
+		h.Set("last-modified", resp.LastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if httpcache.Conditional(r) {
+		if httpcache.NotModified(r, etag, resp.LastModified) {
+			metrics.CacheLookups.Inc("http", "hit")
+			h.Del("content-type")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		metrics.CacheLookups.Inc("http", "miss")
+	}
+
+	w.WriteHeader(resp.Code)
+	w.Write(body.Bytes())
+}
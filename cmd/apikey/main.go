@@ -0,0 +1,128 @@
+// Package main is the entry point for managing the client keys of our own API.
+//
+// Keys are stored hashed in the database: the plain key is printed once, when issued.
+//
+// Usage:
+//
+//	apikey create -name <client> [-scopes read,admin] [-tier default]
+//	apikey list
+//	apikey revoke -id <id>
+//
+// Each command also accepts the flags of config.Load, i.e., -config config.json.
+package main // import "github.com/riacataquian/news/cmd/apikey"
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/riacataquian/news/internal/auth"
+	"github.com/riacataquian/news/internal/config"
+	"github.com/riacataquian/news/internal/store"
+)
+
+const usage = `usage:
+	apikey create -name <client> [-scopes read,admin] [-tier default]
+	apikey list
+	apikey revoke -id <id>`
+
+func main() {
+	if len(os.Args) < 2 {
+		log.Fatal(usage)
+	}
+
+	ctx := context.Background()
+	cmd, args := os.Args[1], os.Args[2:]
+	switch cmd {
+	case "create":
+		create(ctx, args)
+	case "list":
+		list(ctx, args)
+	case "revoke":
+		revoke(ctx, args)
+	default:
+		log.Fatalf("unknown command %q\n%s", cmd, usage)
+	}
+}
+
+// create issues a client key then prints it.
+func create(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("create", flag.ExitOnError)
+	name := fs.String("name", "", "client the key is issued to")
+	scopes := fs.String("scopes", string(auth.Read), "comma-separated scopes granted to the key, read or admin")
+	tier := fs.String("tier", "default", "rate limit tier of the key, see RATE_LIMIT_TIERS")
+	conf, err := config.Load(fs, args)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *name == "" {
+		log.Fatal("missing -name")
+	}
+	granted, err := auth.ParseScopes(*scopes)
+	if err != nil {
+		log.Fatalf("invalid -scopes: %v", err)
+	}
+
+	plain, key, err := auth.NewKey(*name, granted)
+	if err != nil {
+		log.Fatal(err)
+	}
+	key.Tier = *tier
+	if err := store.New(conf.Database).CreateKey(ctx, key); err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("issued client key %d to %s, store it now as it won't be shown again:", key.ID, key.Name)
+	fmt.Println(plain)
+}
+
+// list prints the issued client keys.
+func list(ctx context.Context, args []string) {
+	conf, err := config.Load(flag.NewFlagSet("list", flag.ExitOnError), args)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	keys, err := store.New(conf.Database).ListKeys(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, key := range keys {
+		scopes := make([]string, len(key.Scopes))
+		for i, s := range key.Scopes {
+			scopes[i] = string(s)
+		}
+
+		status := "active"
+		if key.RevokedAt != nil {
+			status = "revoked " + key.RevokedAt.Format(time.RFC3339)
+		}
+		fmt.Printf("%d\t%s\t%s...\t%s\t%s\t%s\n", key.ID, key.Name, key.Prefix, strings.Join(scopes, ","), key.Tier, status)
+	}
+}
+
+// revoke revokes a client key.
+func revoke(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("revoke", flag.ExitOnError)
+	id := fs.Int64("id", 0, "id of the key to revoke, see apikey list")
+	conf, err := config.Load(fs, args)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = store.New(conf.Database).RevokeKey(ctx, *id, time.Now().UTC())
+	if err == auth.ErrKeyNotFound {
+		log.Fatalf("client key %d not found", *id)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("revoked client key %d", *id)
+}
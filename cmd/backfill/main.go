@@ -0,0 +1,70 @@
+// Package main is the entry point for backfilling historical news.
+//
+// It fetches news as per the cron's watchlist for a date range, walking it backwards
+// one day at a time, and persists them to the data repository.
+// Its progress is checkpointed in the database: running it again with the same
+// date range resumes where it stopped, i.e., after a crash or after spending its request budget.
+//
+// Usage:
+//
+//	backfill -from 2018-07-01 -to 2018-07-28 [-budget 100] [-config config.json]
+package main // import "github.com/riacataquian/news/cmd/backfill"
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"github.com/riacataquian/news/internal/app"
+	"github.com/riacataquian/news/internal/auth"
+	"github.com/riacataquian/news/internal/config"
+	"github.com/riacataquian/news/internal/ingestion"
+	"github.com/riacataquian/news/internal/store"
+	"github.com/riacataquian/news/web/cron"
+)
+
+// dateFormat is the expected format of the -from and -to flags.
+const dateFormat = "2006-01-02"
+
+func main() {
+	from := flag.String("from", "", "oldest date to backfill, i.e., 2018-07-01")
+	to := flag.String("to", "", "newest date to backfill, i.e., 2018-07-28")
+	budget := flag.Int("budget", 100, "maximum count of requests to newsapi")
+	conf, err := config.Load(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	opts := cron.Options{
+		Trigger: ingestion.Backfill,
+		Budget:  *budget,
+	}
+
+	if opts.From, err = time.Parse(dateFormat, *from); err != nil {
+		log.Fatalf("invalid -from date, expects %s: %v", dateFormat, err)
+	}
+	if opts.To, err = time.Parse(dateFormat, *to); err != nil {
+		log.Fatalf("invalid -to date, expects %s: %v", dateFormat, err)
+	}
+
+	keys := auth.NewKeyPool(conf.NewsAPI.APIKeys(), conf.NewsAPI.Cooldown.Duration)
+	if len(keys.Usage()) == 0 {
+		log.Fatal(auth.ErrMissingAPIKey)
+	}
+
+	a := app.New(conf, store.New(conf.Database), keys)
+	run, err := cron.Run(context.Background(), a, cron.BackfillJob, opts)
+	if err == ingestion.ErrBudgetExhausted {
+		log.Printf("backfill run %d paused after spending its budget of %d requests, run again to resume", run.ID, *budget)
+		log.Printf("fetched %d articles, persisted %d articles", run.ArticlesFetched, run.ArticlesPersisted)
+		return
+	}
+	if err != nil {
+		log.Fatalf("backfill failed: %v", err)
+	}
+
+	log.Printf("backfill run %d finished", run.ID)
+	log.Printf("fetched %d articles, persisted %d articles", run.ArticlesFetched, run.ArticlesPersisted)
+}
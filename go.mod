@@ -1,6 +1,7 @@
 module github.com/riacataquian/news
 
 require (
+	github.com/andybalholm/brotli v1.1.0
 	github.com/go-sql-driver/mysql v1.4.0 // indirect
 	github.com/gorilla/context v1.1.1 // indirect
 	github.com/gorilla/mux v1.6.2
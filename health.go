@@ -0,0 +1,27 @@
+package main
+
+// This file contains the readiness checks of the server's dependencies.
+
+import (
+	"context"
+
+	"github.com/riacataquian/news/internal/app"
+	"github.com/riacataquian/news/internal/auth"
+	"github.com/riacataquian/news/internal/health"
+	"github.com/riacataquian/news/internal/store"
+)
+
+// readiness returns the checks of /readyz: the database answers, its schema is migrated,
+// and newsapi keys are configured.
+func readiness(a *app.App, repo *store.Repo) []health.Check {
+	return []health.Check{
+		{Name: "database", Fn: repo.PingContext},
+		{Name: "migrations", Fn: repo.CheckMigrations},
+		{Name: "apiKeys", Fn: func(context.Context) error {
+			if len(a.APIKeys.Usage()) == 0 {
+				return auth.ErrMissingAPIKey
+			}
+			return nil
+		}},
+	}
+}
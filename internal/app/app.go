@@ -0,0 +1,47 @@
+// Package app contains the dependencies shared by the HTTP handlers and the cron jobs.
+//
+// An App is built once, then passed to each handler and job instead of package-level globals,
+// so that concurrent requests don't race on them and tests can run in parallel with their own fakes.
+package app
+
+import (
+	"github.com/riacataquian/news/internal/auth"
+	"github.com/riacataquian/news/internal/clock"
+	"github.com/riacataquian/news/internal/config"
+	"github.com/riacataquian/news/internal/newsclient"
+	"github.com/riacataquian/news/internal/newsclient/headlines"
+	"github.com/riacataquian/news/internal/newsclient/list"
+	"github.com/riacataquian/news/internal/store"
+)
+
+// App holds the configuration, the data repository, the clock and the newsapi clients.
+//
+// Its fields are set once, before it's shared; App is then safe for concurrent use.
+type App struct {
+	Config *config.Config
+	Store  store.Store
+	Clock  clock.Time
+
+	// APIKeys is the pool of newsapi keys the clients authenticate with.
+	APIKeys *auth.KeyPool
+	// List and Headlines are the clients of newsapi's everything and top-headlines endpoints.
+	List      newsclient.HTTPClient
+	Headlines newsclient.HTTPClient
+}
+
+// New returns an App as per conf, storing data in repo and authenticating to newsapi with keys.
+func New(conf *config.Config, repo store.Store, keys *auth.KeyPool) *App {
+	listEndpoint := list.ServiceEndpoint
+	listEndpoint.RequestURL = conf.NewsAPI.ListURL
+	headlinesEndpoint := headlines.ServiceEndpoint
+	headlinesEndpoint.RequestURL = conf.NewsAPI.HeadlinesURL
+
+	return &App{
+		Config:    conf,
+		Store:     repo,
+		Clock:     clock.New(),
+		APIKeys:   keys,
+		List:      newsclient.New(listEndpoint),
+		Headlines: newsclient.New(headlinesEndpoint),
+	}
+}
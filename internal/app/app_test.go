@@ -0,0 +1,41 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+	"github.com/riacataquian/news/internal/auth"
+	"github.com/riacataquian/news/internal/config"
+	"github.com/riacataquian/news/internal/newsclient"
+	"github.com/riacataquian/news/internal/newsclient/headlines"
+	"github.com/riacataquian/news/internal/newsclient/list"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	conf := config.Default()
+	conf.NewsAPI.ListURL = "http://localhost:8080/everything"
+	conf.NewsAPI.HeadlinesURL = "http://localhost:8080/top-headlines"
+	keys := auth.NewKeyPool([]string{"test-api-key"}, auth.DefaultCooldown)
+
+	got := New(conf, nil, keys)
+	if got.Config != conf || got.APIKeys != keys || got.Clock == nil {
+		t.Errorf("New(_, _, _): want an app with the supplied configuration, keys and a clock, got %+v", got)
+	}
+
+	want := map[string]newsclient.ServiceEndpoint{
+		"List":      {RequestURL: conf.NewsAPI.ListURL, DocsURL: list.ServiceEndpoint.DocsURL},
+		"Headlines": {RequestURL: conf.NewsAPI.HeadlinesURL, DocsURL: headlines.ServiceEndpoint.DocsURL},
+	}
+	clients := map[string]newsclient.HTTPClient{"List": got.List, "Headlines": got.Headlines}
+	for name, client := range clients {
+		c, ok := client.(*newsclient.Client)
+		if !ok {
+			t.Fatalf("New(_, _, _): want %s to be a *newsclient.Client, got %T", name, client)
+		}
+		if diff := pretty.Compare(c.ServiceEndpoint, want[name]); diff != "" {
+			t.Errorf("New(_, _, _): %s endpoint diff: (-got +want)\n%s", name, diff)
+		}
+	}
+}
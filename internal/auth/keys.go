@@ -0,0 +1,175 @@
+package auth
+
+// This file contains client keys authenticating requests to our own API.
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Scope is a permission granted to a client key.
+type Scope string
+
+// Scopes of a client key.
+const (
+	// Read allows querying news and ingestion runs.
+	Read Scope = "read"
+	// Admin allows everything, including admin routes.
+	Admin Scope = "admin"
+	// Public is the scope of the routes requiring no client key, i.e., the one of unknown paths.
+	// It can't be granted, see ParseScopes, and routes missing a scope require a key rather than none.
+	Public Scope = "public"
+)
+
+// keyPrefix is the prefix of issued client keys, i.e., nk_3q2-7w...
+const keyPrefix = "nk_"
+
+var (
+	// ErrMissingKey is the error message for requests without a client key.
+	ErrMissingKey = errors.New("missing client key")
+
+	// ErrInvalidKey is the error message for unknown client keys.
+	ErrInvalidKey = errors.New("invalid client key")
+
+	// ErrKeyRevoked is the error message for revoked client keys.
+	ErrKeyRevoked = errors.New("client key is revoked")
+
+	// ErrInsufficientScope is the error message for client keys not granted a route's scope.
+	ErrInsufficientScope = errors.New("client key is not allowed to access this resource")
+
+	// ErrKeyNotFound is the error message for missing client keys in a KeyStore.
+	ErrKeyNotFound = errors.New("client key not found")
+
+	// ErrInvalidScope is the error message for unknown scopes.
+	ErrInvalidScope = errors.New("invalid scope")
+)
+
+// KeyStore describes a repository of client keys.
+//
+// Only the keys' hashes are stored, the plain keys are shown once when issued.
+type KeyStore interface {
+	// CreateKey inserts key, setting its ID.
+	CreateKey(ctx context.Context, key *Key) error
+	// GetKey returns the key matching hash, or ErrKeyNotFound if none.
+	GetKey(ctx context.Context, hash string) (*Key, error)
+	// ListKeys returns the keys, newest first.
+	ListKeys(ctx context.Context) ([]*Key, error)
+	// RevokeKey marks the key matching id as revoked at the supplied time, or returns ErrKeyNotFound if none.
+	RevokeKey(ctx context.Context, id int64, at time.Time) error
+}
+
+// Key is an issued client key.
+type Key struct {
+	ID int64 `json:"id"`
+	// Name describes the client the key is issued to.
+	Name string `json:"name"`
+	// Prefix is the beginning of the plain key, to tell keys apart.
+	Prefix string `json:"prefix"`
+	// Hash is the SHA-256 hash of the plain key.
+	Hash   string  `json:"-"`
+	Scopes []Scope `json:"scopes"`
+	// Tier names the key's rate limits, see package ratelimit.
+	Tier      string     `json:"tier"`
+	CreatedAt time.Time  `json:"createdAt"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+}
+
+// NewKey generates a client key for name granted scopes, in the default rate limit tier.
+// It returns the plain key to hand to the client, and the Key to store.
+func NewKey(name string, scopes []Scope) (string, *Key, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, fmt.Errorf("generating client key: %v", err)
+	}
+
+	plain := keyPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return plain, &Key{
+		Name:      name,
+		Prefix:    plain[:len(keyPrefix)+6],
+		Hash:      HashKey(plain),
+		Scopes:    scopes,
+		Tier:      "default",
+		CreatedAt: time.Now().UTC(),
+	}, nil
+}
+
+// HashKey returns the hex-encoded SHA-256 hash of the plain key.
+func HashKey(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
+
+// ParseScopes parses a comma-separated list of scopes, i.e., "read,admin".
+func ParseScopes(s string) ([]Scope, error) {
+	var scopes []Scope
+	for _, v := range strings.Split(s, ",") {
+		scope := Scope(strings.TrimSpace(v))
+		if scope != Read && scope != Admin {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidScope, v)
+		}
+		scopes = append(scopes, scope)
+	}
+	return scopes, nil
+}
+
+// Allows reports whether k is granted scope. The Admin scope allows every scope.
+func (k *Key) Allows(scope Scope) bool {
+	for _, s := range k.Scopes {
+		if s == scope || s == Admin {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticate returns the key of the request's `Authorization: Bearer <key>` header
+// if it's found in keys, not revoked and granted scope.
+func Authenticate(ctx context.Context, keys KeyStore, r *http.Request, scope Scope) (*Key, error) {
+	h := r.Header.Get("Authorization")
+	if !strings.HasPrefix(h, "Bearer ") {
+		return nil, ErrMissingKey
+	}
+	plain := strings.TrimSpace(strings.TrimPrefix(h, "Bearer "))
+	if plain == "" {
+		return nil, ErrMissingKey
+	}
+
+	// Keys are looked up by hash so the comparison doesn't leak the plain key's timing.
+	key, err := keys.GetKey(ctx, HashKey(plain))
+	if err == ErrKeyNotFound {
+		return nil, ErrInvalidKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if key.RevokedAt != nil {
+		return nil, ErrKeyRevoked
+	}
+	if !key.Allows(scope) {
+		return nil, ErrInsufficientScope
+	}
+	return key, nil
+}
+
+// keyContextKey is the context key of the authenticated Key.
+type keyContextKey struct{}
+
+// NewContext returns a copy of ctx carrying the authenticated key.
+func NewContext(ctx context.Context, key *Key) context.Context {
+	return context.WithValue(ctx, keyContextKey{}, key)
+}
+
+// FromContext returns the authenticated key carried by ctx, if any.
+func FromContext(ctx context.Context) (*Key, bool) {
+	key, ok := ctx.Value(keyContextKey{}).(*Key)
+	return key, ok
+}
@@ -0,0 +1,53 @@
+package auth
+
+// This file contains fake implementations used for testing.
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+type fakekeystore struct {
+	isError bool
+	// keys are the stored keys, by hash.
+	keys map[string]*Key
+}
+
+func (f *fakekeystore) CreateKey(_ context.Context, key *Key) error {
+	if f.keys == nil {
+		f.keys = make(map[string]*Key)
+	}
+	key.ID = int64(len(f.keys) + 1)
+	f.keys[key.Hash] = key
+	return nil
+}
+
+func (f *fakekeystore) GetKey(_ context.Context, hash string) (*Key, error) {
+	if f.isError {
+		return nil, errors.New("some store error")
+	}
+	key, ok := f.keys[hash]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	return key, nil
+}
+
+func (f *fakekeystore) ListKeys(_ context.Context) ([]*Key, error) {
+	var keys []*Key
+	for _, key := range f.keys {
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func (f *fakekeystore) RevokeKey(_ context.Context, id int64, at time.Time) error {
+	for _, key := range f.keys {
+		if key.ID == id {
+			key.RevokedAt = &at
+			return nil
+		}
+	}
+	return ErrKeyNotFound
+}
@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestNewKey(t *testing.T) {
+	plain, key, err := NewKey("some-client", []Scope{Read})
+	if err != nil {
+		t.Fatalf("NewKey(_, _): want (_, _, nil), got (_, _, %v)", err)
+	}
+
+	if !strings.HasPrefix(plain, keyPrefix) || !strings.HasPrefix(plain, key.Prefix) {
+		t.Errorf("NewKey(_, _): want a plain key prefixed with %q and %q, got %q", keyPrefix, key.Prefix, plain)
+	}
+	if key.Hash != HashKey(plain) || strings.Contains(key.Hash, plain) {
+		t.Errorf("NewKey(_, _): want the key's hash to be HashKey(%q), got %q", plain, key.Hash)
+	}
+
+	other, _, _ := NewKey("some-client", []Scope{Read})
+	if other == plain {
+		t.Errorf("NewKey(_, _): want unique keys, got %q twice", plain)
+	}
+}
+
+func TestParseScopes(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []Scope
+		wantErr error
+	}{
+		{in: "read", want: []Scope{Read}},
+		{in: "read, admin", want: []Scope{Read, Admin}},
+		{in: "write", wantErr: ErrInvalidScope},
+		{in: "public", wantErr: ErrInvalidScope},
+		{in: "", wantErr: ErrInvalidScope},
+	}
+
+	for _, test := range tests {
+		got, err := ParseScopes(test.in)
+		if !errors.Is(err, test.wantErr) {
+			t.Errorf("ParseScopes(%q): want (_, %v), got (_, %v)", test.in, test.wantErr, err)
+		}
+		if diff := pretty.Compare(got, test.want); diff != "" {
+			t.Errorf("ParseScopes(%q) diff: (-got +want)\n%s", test.in, diff)
+		}
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	keys := &fakekeystore{}
+	readKey, key, _ := NewKey("reader", []Scope{Read})
+	keys.CreateKey(context.Background(), key)
+	adminKey, key, _ := NewKey("admin", []Scope{Admin})
+	keys.CreateKey(context.Background(), key)
+	revokedKey, key, _ := NewKey("revoked", []Scope{Admin})
+	keys.CreateKey(context.Background(), key)
+	keys.RevokeKey(context.Background(), key.ID, time.Date(2018, time.July, 28, 0, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		desc          string
+		authorization string
+		scope         Scope
+		wantName      string
+		wantErr       error
+	}{
+		{
+			desc:          "authenticates a key granted the scope",
+			authorization: "Bearer " + readKey,
+			scope:         Read,
+			wantName:      "reader",
+		},
+		{
+			desc:          "authenticates an admin key for any scope",
+			authorization: "Bearer " + adminKey,
+			scope:         Read,
+			wantName:      "admin",
+		},
+		{
+			desc:          "rejects a key lacking the scope",
+			authorization: "Bearer " + readKey,
+			scope:         Admin,
+			wantErr:       ErrInsufficientScope,
+		},
+		{
+			desc:    "rejects a request without a key",
+			scope:   Read,
+			wantErr: ErrMissingKey,
+		},
+		{
+			desc:          "rejects a request with another authorization scheme",
+			authorization: "Basic " + readKey,
+			scope:         Read,
+			wantErr:       ErrMissingKey,
+		},
+		{
+			desc:          "rejects an unknown key",
+			authorization: "Bearer nk_some-unknown-key",
+			scope:         Read,
+			wantErr:       ErrInvalidKey,
+		},
+		{
+			desc:          "rejects a revoked key",
+			authorization: "Bearer " + revokedKey,
+			scope:         Read,
+			wantErr:       ErrKeyRevoked,
+		},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/list", nil)
+		if test.authorization != "" {
+			r.Header.Set("Authorization", test.authorization)
+		}
+
+		got, err := Authenticate(context.Background(), keys, r, test.scope)
+		if err != test.wantErr {
+			t.Fatalf("%s: Authenticate(_, _, _, %q): want (_, %v), got (%v, %v)", test.desc, test.scope, test.wantErr, got, err)
+		}
+		if err == nil && got.Name != test.wantName {
+			t.Errorf("%s: Authenticate(_, _, _, %q): want key of %s, got %+v", test.desc, test.scope, test.wantName, got)
+		}
+	}
+}
+
+func TestAuthenticateStoreError(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/list", nil)
+	r.Header.Set("Authorization", "Bearer nk_some-key")
+
+	got, err := Authenticate(context.Background(), &fakekeystore{isError: true}, r, Read)
+	if err == nil || err == ErrInvalidKey {
+		t.Errorf("Authenticate(_, _, _, _): want (nil, store error), got (%v, %v)", got, err)
+	}
+}
+
+func TestContext(t *testing.T) {
+	key := &Key{ID: 1, Name: "some-client"}
+	if got, ok := FromContext(NewContext(context.Background(), key)); !ok || got != key {
+		t.Errorf("FromContext(NewContext(_, %v)): want (%v, true), got (%v, %v)", key, key, got, ok)
+	}
+	if got, ok := FromContext(context.Background()); ok {
+		t.Errorf("FromContext(_): want (nil, false), got (%v, %v)", got, ok)
+	}
+}
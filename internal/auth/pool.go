@@ -0,0 +1,266 @@
+package auth
+
+// This file contains a pool of newsapi keys, rotated round-robin.
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/riacataquian/news/internal/clock"
+	"github.com/riacataquian/news/internal/httperror"
+)
+
+// DefaultCooldown is how long a key is quarantined after it's exhausted or rate limited.
+const DefaultCooldown = time.Hour
+
+// newsapi error codes, see https://newsapi.org/docs/errors.
+const (
+	codeKeyExhausted = "apiKeyExhausted"
+	codeRateLimited  = "rateLimited"
+	codeKeyDisabled  = "apiKeyDisabled"
+)
+
+// ErrNoAvailableKey is the error message for a pool whose keys are all quarantined or removed.
+var ErrNoAvailableKey = errors.New("no available API key, all keys are quarantined or disabled")
+
+// KeyPool rotates requests to newsapi among several API keys.
+//
+// Keys are handed out round-robin. A key that newsapi reports as exhausted or rate limited
+// is quarantined for a cooldown period, a key reported as disabled is removed from the rotation.
+// KeyPool is safe for concurrent use.
+type KeyPool struct {
+	mu       sync.Mutex
+	keys     []*poolKey
+	next     int
+	cooldown time.Duration
+	clock    clock.Time
+	// lastErr is the last failed request, if any.
+	lastErr *UpstreamError
+}
+
+// poolKey is a key of a KeyPool and its usage.
+type poolKey struct {
+	key              string
+	requests         int
+	failures         int
+	quarantinedUntil time.Time
+	removed          bool
+}
+
+// KeyUsage describes the usage of a key of a KeyPool.
+type KeyUsage struct {
+	// Key is the masked key, i.e., 1a2b...
+	Key      string `json:"key"`
+	Requests int    `json:"requests"`
+	Failures int    `json:"failures"`
+	// QuarantinedUntil is set while the key is quarantined.
+	QuarantinedUntil *time.Time `json:"quarantinedUntil,omitempty"`
+	// Removed is true once the key is disabled.
+	Removed bool `json:"removed"`
+}
+
+// KeyCounts are the count of keys of a KeyPool per state.
+type KeyCounts struct {
+	// Available keys are neither quarantined nor removed: they're the quota left.
+	Available   int `json:"available"`
+	Quarantined int `json:"quarantined"`
+	Removed     int `json:"removed"`
+}
+
+// UpstreamError describes the last failed request made with a key of a KeyPool.
+type UpstreamError struct {
+	Message string `json:"message"`
+	// Code is newsapi's error code, if any.
+	Code string    `json:"code,omitempty"`
+	At   time.Time `json:"at"`
+}
+
+// NewKeyPool returns a KeyPool of keys, quarantining keys for cooldown.
+// Duplicate and empty keys are ignored.
+func NewKeyPool(keys []string, cooldown time.Duration) *KeyPool {
+	p := &KeyPool{cooldown: cooldown, clock: clock.New()}
+	seen := make(map[string]bool)
+	for _, k := range keys {
+		k = strings.TrimSpace(k)
+		if k == "" || seen[k] {
+			continue
+		}
+		seen[k] = true
+		p.keys = append(p.keys, &poolKey{key: k})
+	}
+	return p
+}
+
+// Reload replaces the keys of the pool and its cooldown, i.e., once the keys' secret is reloaded.
+// The usage of the keys still in the pool is kept.
+// It returns ErrMissingAPIKey, leaving the pool unchanged, if keys is empty.
+func (p *KeyPool) Reload(keys []string, cooldown time.Duration) error {
+	fresh := NewKeyPool(keys, cooldown)
+	if len(fresh.keys) == 0 {
+		return ErrMissingAPIKey
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, k := range fresh.keys {
+		if prev := p.lookup(k.key); prev != nil {
+			fresh.keys[i] = prev
+		}
+	}
+	p.keys = fresh.keys
+	p.cooldown = cooldown
+	p.next = 0
+	return nil
+}
+
+// Next returns the next available key, counting a request against it.
+// It returns ErrMissingAPIKey if the pool is empty, or ErrNoAvailableKey if no key is available.
+func (p *KeyPool) Next() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.keys) == 0 {
+		return "", ErrMissingAPIKey
+	}
+
+	now := p.clock.Now()
+	for i := 0; i < len(p.keys); i++ {
+		k := p.keys[(p.next+i)%len(p.keys)]
+		if k.removed || now.Before(k.quarantinedUntil) {
+			continue
+		}
+
+		p.next = (p.next + i + 1) % len(p.keys)
+		k.requests++
+		return k.key, nil
+	}
+	return "", ErrNoAvailableKey
+}
+
+// Report records the outcome of a request made with key.
+// It quarantines or removes key as per newsapi's error code, returning true if it did.
+func (p *KeyPool) Report(key string, err error) bool {
+	if err == nil {
+		return false
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.lastErr = &UpstreamError{Message: err.Error(), Code: httperror.UpstreamCode(err), At: p.clock.Now()}
+	k := p.lookup(key)
+	if k == nil {
+		return false
+	}
+	k.failures++
+
+	switch httperror.UpstreamCode(err) {
+	case codeKeyExhausted, codeRateLimited:
+		k.quarantinedUntil = p.clock.Now().Add(p.cooldown)
+		return true
+	case codeKeyDisabled:
+		k.removed = true
+		return true
+	default:
+		return false
+	}
+}
+
+// Do calls fn with the next available key, then reports its outcome.
+// It fails over to the next keys while fn fails with an error that quarantines or removes the key.
+func (p *KeyPool) Do(fn func(key string) error) error {
+	key, err := p.Next()
+	if err != nil {
+		return err
+	}
+
+	for tries := 1; ; tries++ {
+		err = fn(key)
+		if !p.Report(key, err) || tries >= p.size() {
+			return err
+		}
+
+		next, nextErr := p.Next()
+		if nextErr != nil {
+			// Report why the last key failed rather than that none is left.
+			return err
+		}
+		key = next
+	}
+}
+
+// Usage returns the usage of each key of the pool, in order.
+func (p *KeyPool) Usage() []KeyUsage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.clock.Now()
+	usage := make([]KeyUsage, len(p.keys))
+	for i, k := range p.keys {
+		usage[i] = KeyUsage{
+			Key:      mask(k.key),
+			Requests: k.requests,
+			Failures: k.failures,
+			Removed:  k.removed,
+		}
+		if now.Before(k.quarantinedUntil) {
+			t := k.quarantinedUntil
+			usage[i].QuarantinedUntil = &t
+		}
+	}
+	return usage
+}
+
+// Counts returns the count of keys of the pool per state.
+func (p *KeyPool) Counts() KeyCounts {
+	var c KeyCounts
+	for _, u := range p.Usage() {
+		switch {
+		case u.Removed:
+			c.Removed++
+		case u.QuarantinedUntil != nil:
+			c.Quarantined++
+		default:
+			c.Available++
+		}
+	}
+	return c
+}
+
+// LastError returns the last failed request made with a key of the pool, if any.
+func (p *KeyPool) LastError() *UpstreamError {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.lastErr == nil {
+		return nil
+	}
+	e := *p.lastErr
+	return &e
+}
+
+// size returns the count of keys in the pool.
+func (p *KeyPool) size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.keys)
+}
+
+// lookup returns the poolKey of key, if any. p.mu must be held.
+func (p *KeyPool) lookup(key string) *poolKey {
+	for _, k := range p.keys {
+		if k.key == key {
+			return k
+		}
+	}
+	return nil
+}
+
+// mask hides all but the first characters of key.
+func mask(key string) string {
+	if len(key) <= 4 {
+		return "..."
+	}
+	return key[:4] + "..."
+}
@@ -0,0 +1,190 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+	"github.com/riacataquian/news/api/news"
+	"github.com/riacataquian/news/internal/clock"
+)
+
+func TestKeyPoolNext(t *testing.T) {
+	p := NewKeyPool([]string{"key-1", "key-2", "key-1", " "}, DefaultCooldown)
+
+	var got []string
+	for i := 0; i < 3; i++ {
+		key, err := p.Next()
+		if err != nil {
+			t.Fatalf("Next(): want (_, nil), got (_, %v)", err)
+		}
+		got = append(got, key)
+	}
+
+	want := []string{"key-1", "key-2", "key-1"}
+	if diff := pretty.Compare(got, want); diff != "" {
+		t.Errorf("Next() diff: (-got +want)\n%s", diff)
+	}
+
+	if key, err := NewKeyPool(nil, DefaultCooldown).Next(); err != ErrMissingAPIKey {
+		t.Errorf("Next(): want (_, ErrMissingAPIKey) for an empty pool, got (%q, %v)", key, err)
+	}
+}
+
+func TestKeyPoolReport(t *testing.T) {
+	fake := clock.NewFake(time.Date(2018, time.July, 28, 0, 0, 0, 0, time.UTC))
+	p := NewKeyPool([]string{"key-1", "key-2", "key-3"}, time.Hour)
+	p.clock = fake
+
+	tests := []struct {
+		desc string
+		key  string
+		err  error
+		want bool
+	}{
+		{
+			desc: "ignores successful requests",
+			key:  "key-1",
+		},
+		{
+			desc: "ignores errors not caused by the key",
+			key:  "key-1",
+			err:  &news.ErrorResponse{Code: "parameterInvalid"},
+		},
+		{
+			desc: "quarantines exhausted keys",
+			key:  "key-1",
+			err:  &news.ErrorResponse{Code: "apiKeyExhausted"},
+			want: true,
+		},
+		{
+			desc: "removes disabled keys",
+			key:  "key-2",
+			err:  &news.ErrorResponse{Code: "apiKeyDisabled"},
+			want: true,
+		},
+	}
+
+	for _, test := range tests {
+		if got := p.Report(test.key, test.err); got != test.want {
+			t.Errorf("%s: Report(%q, %v): want %v, got %v", test.desc, test.key, test.err, test.want, got)
+		}
+	}
+
+	for i := 0; i < 2; i++ {
+		if key, err := p.Next(); key != "key-3" || err != nil {
+			t.Errorf("Next(): want (key-3, nil) while the other keys are unavailable, got (%q, %v)", key, err)
+		}
+	}
+
+	rateLimited := &news.ErrorResponse{Code: "rateLimited"}
+	p.Report("key-3", rateLimited)
+	if key, err := p.Next(); err != ErrNoAvailableKey {
+		t.Errorf("Next(): want (_, ErrNoAvailableKey) when all keys are unavailable, got (%q, %v)", key, err)
+	}
+
+	until := time.Date(2018, time.July, 28, 1, 0, 0, 0, time.UTC)
+	wantUsage := []KeyUsage{
+		{Key: "key-...", Failures: 2, QuarantinedUntil: &until},
+		{Key: "key-...", Failures: 1, Removed: true},
+		{Key: "key-...", Requests: 2, Failures: 1, QuarantinedUntil: &until},
+	}
+	if diff := pretty.Compare(p.Usage(), wantUsage); diff != "" {
+		t.Errorf("Usage() diff: (-got +want)\n%s", diff)
+	}
+	if diff := pretty.Compare(p.Counts(), KeyCounts{Quarantined: 2, Removed: 1}); diff != "" {
+		t.Errorf("Counts() diff: (-got +want)\n%s", diff)
+	}
+	wantErr := &UpstreamError{Message: rateLimited.Error(), Code: "rateLimited", At: fake.Now()}
+	if diff := pretty.Compare(p.LastError(), wantErr); diff != "" {
+		t.Errorf("LastError() diff: (-got +want)\n%s", diff)
+	}
+
+	// Quarantined keys are back after the cooldown, removed keys are not.
+	fake.Advance(time.Hour)
+	var got []string
+	for i := 0; i < 3; i++ {
+		key, _ := p.Next()
+		got = append(got, key)
+	}
+	if diff := pretty.Compare(got, []string{"key-1", "key-3", "key-1"}); diff != "" {
+		t.Errorf("Next() after the cooldown diff: (-got +want)\n%s", diff)
+	}
+}
+
+func TestKeyPoolDo(t *testing.T) {
+	exhausted := &news.ErrorResponse{Code: "apiKeyExhausted", Message: "exhausted"}
+	tests := []struct {
+		desc     string
+		errs     map[string]error
+		wantKeys []string
+		wantErr  error
+	}{
+		{
+			desc:     "calls fn with the next key",
+			wantKeys: []string{"key-1"},
+		},
+		{
+			desc:     "fails over to the next key",
+			errs:     map[string]error{"key-1": exhausted},
+			wantKeys: []string{"key-1", "key-2"},
+		},
+		{
+			desc:     "returns the last error when all keys fail",
+			errs:     map[string]error{"key-1": exhausted, "key-2": exhausted},
+			wantKeys: []string{"key-1", "key-2"},
+			wantErr:  exhausted,
+		},
+		{
+			desc:     "doesn't fail over on other errors",
+			errs:     map[string]error{"key-1": errors.New("some error")},
+			wantKeys: []string{"key-1"},
+			wantErr:  errors.New("some error"),
+		},
+	}
+
+	for _, test := range tests {
+		p := NewKeyPool([]string{"key-1", "key-2"}, DefaultCooldown)
+
+		var gotKeys []string
+		err := p.Do(func(key string) error {
+			gotKeys = append(gotKeys, key)
+			return test.errs[key]
+		})
+
+		if diff := pretty.Compare(err, test.wantErr); diff != "" {
+			t.Errorf("%s: Do(_) error diff: (-got +want)\n%s", test.desc, diff)
+		}
+		if diff := pretty.Compare(gotKeys, test.wantKeys); diff != "" {
+			t.Errorf("%s: Do(_) keys diff: (-got +want)\n%s", test.desc, diff)
+		}
+	}
+}
+
+func TestKeyPoolReload(t *testing.T) {
+	p := NewKeyPool([]string{"key-1", "key-2"}, DefaultCooldown)
+	p.Next()
+
+	if err := p.Reload([]string{"key-1", "key-3"}, time.Minute); err != nil {
+		t.Fatalf("Reload(_, _): want nil, got %v", err)
+	}
+
+	want := []KeyUsage{{Key: "key-...", Requests: 1}, {Key: "key-..."}}
+	if diff := pretty.Compare(p.Usage(), want); diff != "" {
+		t.Errorf("Reload(_, _) usage diff: (-got +want)\n%s", diff)
+	}
+	if key, _ := p.Next(); key != "key-1" {
+		t.Errorf("Next(): want key-1 after a reload, got %q", key)
+	}
+	if key, _ := p.Next(); key != "key-3" {
+		t.Errorf("Next(): want the reloaded key-3, got %q", key)
+	}
+
+	if err := p.Reload([]string{" "}, time.Minute); err != ErrMissingAPIKey {
+		t.Errorf("Reload(_, _): want ErrMissingAPIKey when no key is supplied, got %v", err)
+	}
+	if got := len(p.Usage()); got != 2 {
+		t.Errorf("Reload(_, _): want the pool unchanged on error, got %d keys", got)
+	}
+}
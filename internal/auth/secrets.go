@@ -0,0 +1,127 @@
+package auth
+
+// This file contains helpers for reloading secrets.
+
+import (
+	"crypto/sha256"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/riacataquian/news/internal/clock"
+)
+
+// Reloader calls its reload functions on SIGHUP, or when the content of one of its files changes.
+//
+// Files are polled, so that replaced mounted secrets, i.e., symlinks swapped by Kubernetes, are noticed.
+type Reloader struct {
+	files    []string
+	reloads  []func() error
+	interval time.Duration
+	clock    clock.Time
+
+	// sighup receives the SIGHUP signals.
+	sighup chan os.Signal
+	stop   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewReloader returns a Reloader polling files every interval. Empty paths are ignored.
+func NewReloader(interval time.Duration, files ...string) *Reloader {
+	r := &Reloader{
+		interval: interval,
+		clock:    clock.New(),
+		sighup:   make(chan os.Signal, 1),
+		stop:     make(chan struct{}),
+	}
+	for _, f := range files {
+		if f != "" {
+			r.files = append(r.files, f)
+		}
+	}
+	return r
+}
+
+// OnReload registers fn to be called on reload.
+// It should be called before Start.
+func (r *Reloader) OnReload(fn func() error) {
+	r.reloads = append(r.reloads, fn)
+}
+
+// Start listens to SIGHUP and polls the files in the background until Stop is called.
+func (r *Reloader) Start() {
+	signal.Notify(r.sighup, syscall.SIGHUP)
+
+	// Hash the files before returning, so that changes made after Start are noticed.
+	sums := r.checksums()
+	ticker := r.clock.NewTicker(r.interval)
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-r.stop:
+				return
+			case <-r.sighup:
+				log.Print("reloading secrets on SIGHUP")
+				sums = r.checksums()
+				r.reload()
+			case <-ticker.C():
+				cur := r.checksums()
+				if changed(sums, cur) {
+					log.Print("reloading secrets on file change")
+					sums = cur
+					r.reload()
+				}
+			}
+		}
+	}()
+}
+
+// Stop stops listening to SIGHUP and polling the files.
+func (r *Reloader) Stop() {
+	signal.Stop(r.sighup)
+	close(r.stop)
+	r.wg.Wait()
+}
+
+// reload calls the reload functions, logging their errors.
+func (r *Reloader) reload() {
+	for _, fn := range r.reloads {
+		if err := fn(); err != nil {
+			log.Printf("error reloading secrets: %v", err)
+		}
+	}
+}
+
+// checksums returns the checksum of each file, by path.
+// Missing files, i.e., while a secret is being replaced, have no checksum.
+func (r *Reloader) checksums() map[string][sha256.Size]byte {
+	sums := make(map[string][sha256.Size]byte)
+	for _, f := range r.files {
+		b, err := ioutil.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		sums[f] = sha256.Sum256(b)
+	}
+	return sums
+}
+
+// changed reports whether a file changed between the old and cur checksums.
+// A file gone missing isn't a change, it's reloaded once it's back.
+func changed(old, cur map[string][sha256.Size]byte) bool {
+	for f, sum := range cur {
+		if prev, ok := old[f]; !ok || prev != sum {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/riacataquian/news/internal/clock"
+)
+
+// writeSecret writes a secret file named name to dir then returns its path.
+func writeSecret(t *testing.T, dir, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReloader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secrets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := writeSecret(t, dir, "password", "some-secret")
+
+	fake := clock.NewFake(time.Date(2018, time.July, 28, 0, 0, 0, 0, time.UTC))
+	reloaded := make(chan bool, 1)
+	r := NewReloader(time.Minute, path, "")
+	r.clock = fake
+	r.OnReload(func() error {
+		reloaded <- true
+		return nil
+	})
+	r.Start()
+	defer r.Stop()
+
+	// Wait for the reloader to poll.
+	fake.BlockUntil(1)
+
+	fake.Advance(time.Minute)
+	select {
+	case <-reloaded:
+		t.Fatal("Start(): want no reload while the file is unchanged")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	writeSecret(t, dir, "password", "some-other-secret")
+	fake.Advance(time.Minute)
+	select {
+	case <-reloaded:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Start(): want a reload when the file changed")
+	}
+
+	r.sighup <- syscall.SIGHUP
+	select {
+	case <-reloaded:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Start(): want a reload on SIGHUP")
+	}
+}
@@ -7,6 +7,25 @@ import "time"
 type Time interface {
 	Now() time.Time
 	Since(time.Time) time.Duration
+	After(time.Duration) <-chan time.Time
+	NewTicker(time.Duration) Ticker
+	NewTimer(time.Duration) Timer
+	Sleep(time.Duration)
+}
+
+// Ticker describes a ticker interface, see time.Ticker.
+type Ticker interface {
+	// C returns the channel on which the ticks are delivered.
+	C() <-chan time.Time
+	Stop()
+}
+
+// Timer describes a timer interface, see time.Timer.
+type Timer interface {
+	// C returns the channel on which the time is delivered once the timer fires.
+	C() <-chan time.Time
+	Stop() bool
+	Reset(time.Duration) bool
 }
 
 // Clock abstracts dealing with time.
@@ -28,3 +47,41 @@ func (c Clock) Now() time.Time {
 func (c Clock) Since(start time.Time) time.Duration {
 	return time.Since(start)
 }
+
+// After abstracts time.After(d).
+func (c Clock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// NewTicker abstracts time.NewTicker(d).
+func (c Clock) NewTicker(d time.Duration) Ticker {
+	return &ticker{Ticker: time.NewTicker(d)}
+}
+
+// NewTimer abstracts time.NewTimer(d).
+func (c Clock) NewTimer(d time.Duration) Timer {
+	return &timer{Timer: time.NewTimer(d)}
+}
+
+// Sleep abstracts time.Sleep(d).
+func (c Clock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+// ticker wraps a time.Ticker to implement the Ticker interface.
+type ticker struct {
+	*time.Ticker
+}
+
+func (t *ticker) C() <-chan time.Time {
+	return t.Ticker.C
+}
+
+// timer wraps a time.Timer to implement the Timer interface.
+type timer struct {
+	*time.Timer
+}
+
+func (t *timer) C() <-chan time.Time {
+	return t.Timer.C
+}
@@ -0,0 +1,223 @@
+package clock
+
+// This file contains a fake clock whose time is controlled by the caller.
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Fake implements a clock interface.
+var _ Time = (*Fake)(nil)
+
+// Fake is a clock whose time only moves when told so, with Advance or Set.
+// Timers, tickers and sleepers fire deterministically as the time moves,
+// in order of their deadline then of their creation.
+// Use it to test time-dependent code without real sleeps.
+type Fake struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+	now  time.Time
+	// waiters are the pending timers and tickers.
+	waiters []*waiter
+	// seq orders waiters of the same deadline by creation.
+	seq int
+}
+
+// waiter is a pending fake timer or ticker.
+type waiter struct {
+	fake *Fake
+	at   time.Time
+	seq  int
+	// period is the interval between ticks, zero for timers.
+	period time.Duration
+	c      chan time.Time
+}
+
+// NewFake returns a Fake clock set to now.
+func NewFake(now time.Time) *Fake {
+	f := &Fake{now: now}
+	f.cond = sync.NewCond(&f.mu)
+	return f
+}
+
+// Now returns the fake clock's current time.
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Since returns the fake time elapsed since start.
+func (f *Fake) Since(start time.Time) time.Duration {
+	return f.Now().Sub(start)
+}
+
+// After returns a channel that receives the fake time once the clock moved d forward.
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	return f.NewTimer(d).C()
+}
+
+// NewTicker returns a Ticker that ticks every d as the clock moves forward.
+// Like time.Ticker, ticks are dropped for slow receivers.
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for clock.Fake.NewTicker")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return fakeTicker{f.add(&waiter{at: f.now.Add(d), period: d})}
+}
+
+// NewTimer returns a Timer that fires once the clock moved d forward.
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w := f.add(&waiter{at: f.now.Add(d)})
+	if d <= 0 {
+		f.fire(f.now)
+	}
+	return fakeTimer{w}
+}
+
+// Sleep blocks until the clock moved d forward.
+func (f *Fake) Sleep(d time.Duration) {
+	<-f.After(d)
+}
+
+// Advance moves the clock d forward, firing the timers and tickers due.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.set(f.now.Add(d))
+}
+
+// Set moves the clock to t, firing the timers and tickers due.
+// Moving the clock backwards doesn't fire anything.
+func (f *Fake) Set(t time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.set(t)
+}
+
+// BlockUntil blocks until at least n timers, tickers or sleepers are pending,
+// i.e., until code under test in another goroutine waits on the clock.
+func (f *Fake) BlockUntil(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for len(f.waiters) < n {
+		f.cond.Wait()
+	}
+}
+
+// set moves the clock to t. f.mu must be held.
+func (f *Fake) set(t time.Time) {
+	f.fire(t)
+	f.now = t
+}
+
+// fire delivers the time to the waiters due by t, in order. f.mu must be held.
+func (f *Fake) fire(t time.Time) {
+	for {
+		sort.Slice(f.waiters, func(i, j int) bool {
+			a, b := f.waiters[i], f.waiters[j]
+			if a.at.Equal(b.at) {
+				return a.seq < b.seq
+			}
+			return a.at.Before(b.at)
+		})
+		if len(f.waiters) == 0 || f.waiters[0].at.After(t) {
+			return
+		}
+
+		w := f.waiters[0]
+		f.now = w.at
+		select {
+		case w.c <- w.at:
+		default:
+		}
+
+		if w.period > 0 {
+			w.at = w.at.Add(w.period)
+			continue
+		}
+		f.waiters = f.waiters[1:]
+	}
+}
+
+// add registers w as pending and returns it. f.mu must be held.
+func (f *Fake) add(w *waiter) *waiter {
+	f.seq++
+	w.fake = f
+	w.seq = f.seq
+	w.c = make(chan time.Time, 1)
+	f.waiters = append(f.waiters, w)
+	f.cond.Broadcast()
+	return w
+}
+
+// remove unregisters w, returning false if it wasn't pending. f.mu must be held.
+func (f *Fake) remove(w *waiter) bool {
+	for i, p := range f.waiters {
+		if p == w {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// C returns the channel on which the fake time is delivered.
+func (w *waiter) C() <-chan time.Time {
+	return w.c
+}
+
+// stop unregisters w, returning false if it already fired or was stopped.
+func (w *waiter) stop() bool {
+	w.fake.mu.Lock()
+	defer w.fake.mu.Unlock()
+	return w.fake.remove(w)
+}
+
+// fakeTimer is a Timer of a Fake clock.
+type fakeTimer struct {
+	*waiter
+}
+
+// Stop stops the timer, returning false if it already fired or was stopped.
+func (t fakeTimer) Stop() bool {
+	return t.stop()
+}
+
+// Reset changes the timer to fire once the clock moved d forward from now,
+// returning false if it already fired or was stopped.
+func (t fakeTimer) Reset(d time.Duration) bool {
+	w := t.waiter
+	f := w.fake
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	pending := f.remove(w)
+	w.at = f.now.Add(d)
+	f.seq++
+	w.seq = f.seq
+	f.waiters = append(f.waiters, w)
+	f.cond.Broadcast()
+	if d <= 0 {
+		f.fire(f.now)
+	}
+	return pending
+}
+
+// fakeTicker is a Ticker of a Fake clock.
+type fakeTicker struct {
+	*waiter
+}
+
+// Stop stops the ticker.
+func (t fakeTicker) Stop() {
+	t.stop()
+}
@@ -0,0 +1,126 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+var start = time.Date(2016, time.August, 15, 0, 0, 0, 0, time.UTC)
+
+// fired returns the time received from c, or false if c has nothing to receive.
+func fired(c <-chan time.Time) (time.Time, bool) {
+	select {
+	case t := <-c:
+		return t, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+func TestFakeNowAndSince(t *testing.T) {
+	f := NewFake(start)
+	f.Advance(time.Minute)
+
+	if got, want := f.Now(), start.Add(time.Minute); !got.Equal(want) {
+		t.Errorf("Now(): want %v, got %v", want, got)
+	}
+	if got := f.Since(start); got != time.Minute {
+		t.Errorf("Since(%v): want %v, got %v", start, time.Minute, got)
+	}
+
+	later := start.Add(time.Hour)
+	f.Set(later)
+	if got := f.Now(); !got.Equal(later) {
+		t.Errorf("Set(%v): want Now() %v, got %v", later, later, got)
+	}
+}
+
+func TestFakeTimer(t *testing.T) {
+	f := NewFake(start)
+	timer := f.NewTimer(time.Second)
+
+	f.Advance(999 * time.Millisecond)
+	if got, ok := fired(timer.C()); ok {
+		t.Fatalf("NewTimer(1s): want no fire before 1s, got %v", got)
+	}
+
+	f.Advance(time.Millisecond)
+	if got, ok := fired(timer.C()); !ok || !got.Equal(start.Add(time.Second)) {
+		t.Fatalf("NewTimer(1s): want fire at %v, got (%v, %v)", start.Add(time.Second), got, ok)
+	}
+
+	if timer.Stop() {
+		t.Errorf("Stop(): want false for a fired timer")
+	}
+
+	if timer.Reset(time.Second) {
+		t.Errorf("Reset(1s): want false for a fired timer")
+	}
+	if !timer.Stop() {
+		t.Errorf("Stop(): want true for a pending timer")
+	}
+	f.Advance(time.Hour)
+	if got, ok := fired(timer.C()); ok {
+		t.Errorf("Stop(): want no fire for a stopped timer, got %v", got)
+	}
+}
+
+func TestFakeTicker(t *testing.T) {
+	f := NewFake(start)
+	ticker := f.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for i := 1; i <= 3; i++ {
+		f.Advance(time.Minute)
+		want := start.Add(time.Duration(i) * time.Minute)
+		if got, ok := fired(ticker.C()); !ok || !got.Equal(want) {
+			t.Fatalf("NewTicker(1m): want tick %d at %v, got (%v, %v)", i, want, got, ok)
+		}
+	}
+
+	// Ticks are dropped for slow receivers.
+	f.Advance(3 * time.Minute)
+	if got, ok := fired(ticker.C()); !ok || !got.Equal(start.Add(4*time.Minute)) {
+		t.Errorf("NewTicker(1m): want the first missed tick at %v, got (%v, %v)", start.Add(4*time.Minute), got, ok)
+	}
+	if got, ok := fired(ticker.C()); ok {
+		t.Errorf("NewTicker(1m): want missed ticks to be dropped, got %v", got)
+	}
+}
+
+func TestFakeFiresAtDeadlines(t *testing.T) {
+	f := NewFake(start)
+	late := f.NewTimer(2 * time.Second)
+	early := f.After(time.Second)
+
+	// Timers fire with their own deadline even if the clock jumps past them.
+	f.Advance(time.Hour)
+
+	if got, ok := fired(early); !ok || !got.Equal(start.Add(time.Second)) {
+		t.Errorf("Advance(1h): want the 1s timer to fire at %v, got (%v, %v)", start.Add(time.Second), got, ok)
+	}
+	if got, ok := fired(late.C()); !ok || !got.Equal(start.Add(2*time.Second)) {
+		t.Errorf("Advance(1h): want the 2s timer to fire at %v, got (%v, %v)", start.Add(2*time.Second), got, ok)
+	}
+	if got := f.Now(); !got.Equal(start.Add(time.Hour)) {
+		t.Errorf("Advance(1h): want Now() %v, got %v", start.Add(time.Hour), got)
+	}
+}
+
+func TestFakeSleep(t *testing.T) {
+	f := NewFake(start)
+	done := make(chan struct{})
+	go func() {
+		f.Sleep(time.Minute)
+		close(done)
+	}()
+
+	f.BlockUntil(1)
+	f.Advance(time.Minute)
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Sleep(1m): want to wake up after the clock advanced 1m")
+	}
+}
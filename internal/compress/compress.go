@@ -0,0 +1,245 @@
+// Package compress compresses HTTP responses as negotiated by the requests' Accept-Encoding header.
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+)
+
+// Encoding is a content coding responses are compressed with.
+type Encoding struct {
+	// Name is the coding's token in the Accept-Encoding and Content-Encoding headers, i.e., gzip.
+	Name string
+	// NewWriter returns a writer compressing to w. Closing it flushes the compressed data but doesn't close w.
+	NewWriter func(w io.Writer) io.WriteCloser
+}
+
+// Encodings are the content codings offered, most preferred first when a request accepts several equally:
+// brotli compresses JSON tighter than gzip, which every client supports.
+var Encodings = []Encoding{
+	{Name: "br", NewWriter: newBrotliWriter},
+	{Name: "gzip", NewWriter: newGzipWriter},
+}
+
+// brotliLevel trades brotli's compression ratio for speed, as responses are compressed on the fly.
+const brotliLevel = 5
+
+// gzipWriters and brotliWriters pool the writers, whose allocation dominates the cost of compressing small responses.
+var gzipWriters, brotliWriters sync.Pool
+
+func newGzipWriter(w io.Writer) io.WriteCloser {
+	if zw, ok := gzipWriters.Get().(*gzip.Writer); ok {
+		zw.Reset(w)
+		return &pooledGzipWriter{zw}
+	}
+	return &pooledGzipWriter{gzip.NewWriter(w)}
+}
+
+// pooledGzipWriter returns its gzip.Writer to the pool once closed.
+type pooledGzipWriter struct {
+	*gzip.Writer
+}
+
+// Close implements io.Closer.
+func (zw *pooledGzipWriter) Close() error {
+	err := zw.Writer.Close()
+	gzipWriters.Put(zw.Writer)
+	return err
+}
+
+func newBrotliWriter(w io.Writer) io.WriteCloser {
+	if bw, ok := brotliWriters.Get().(*brotli.Writer); ok {
+		bw.Reset(w)
+		return &pooledBrotliWriter{bw}
+	}
+	return &pooledBrotliWriter{brotli.NewWriterLevel(w, brotliLevel)}
+}
+
+// pooledBrotliWriter returns its brotli.Writer to the pool once closed.
+type pooledBrotliWriter struct {
+	*brotli.Writer
+}
+
+// Close implements io.Closer.
+func (bw *pooledBrotliWriter) Close() error {
+	err := bw.Writer.Close()
+	brotliWriters.Put(bw.Writer)
+	return err
+}
+
+// Handler wraps h, compressing its responses of at least minSize bytes with the encoding the request prefers,
+// see Negotiate. Responses are streamed: once minSize bytes are written, the rest is compressed as it's written.
+//
+// Responses that are already encoded, or without body, i.e., 204 and 304 or to HEAD requests, aren't compressed.
+// The strong ETag of a compressed response is made weak, as its bytes differ from the uncompressed response's.
+func Handler(minSize int, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		enc, ok := Negotiate(r.Header.Get("Accept-Encoding"), Encodings)
+		if !ok || r.Method == http.MethodHead {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &responseWriter{ResponseWriter: w, enc: enc, minSize: minSize}
+		defer cw.Close()
+		h.ServeHTTP(cw, r)
+	})
+}
+
+// Negotiate returns the encoding of encodings the Accept-Encoding header accept prefers, as per its q-values,
+// see https://tools.ietf.org/html/rfc7231#section-5.3.4. It returns false if it accepts none of them.
+func Negotiate(accept string, encodings []Encoding) (Encoding, bool) {
+	qs := make(map[string]float64)
+	for _, part := range strings.Split(accept, ",") {
+		fields := strings.Split(part, ";")
+		name := strings.ToLower(strings.TrimSpace(fields[0]))
+		if name == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		qs[name] = q
+	}
+
+	var (
+		best  Encoding
+		bestQ float64
+	)
+	for _, enc := range encodings {
+		q, ok := qs[enc.Name]
+		if !ok {
+			q, ok = qs["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = enc, q
+		}
+	}
+	return best, bestQ > 0
+}
+
+// responseWriter buffers the response until minSize bytes are written, then compresses it with enc.
+type responseWriter struct {
+	http.ResponseWriter
+	enc     Encoding
+	minSize int
+
+	code int
+	// buf holds the response until it's known whether it's compressed.
+	buf bytes.Buffer
+	// zw compresses the response once decided, nil otherwise.
+	zw io.WriteCloser
+	// decided is set once the response is either compressed or written as is.
+	decided bool
+}
+
+// WriteHeader implements http.ResponseWriter.
+// The status is written along with the first bytes of the body, once it's known whether they're compressed.
+func (cw *responseWriter) WriteHeader(code int) {
+	if cw.code != 0 {
+		return
+	}
+	cw.code = code
+	if !bodyAllowed(code) || cw.Header().Get("Content-Encoding") != "" {
+		cw.decide(false)
+	}
+}
+
+// Write implements http.ResponseWriter.
+func (cw *responseWriter) Write(b []byte) (int, error) {
+	if cw.code == 0 {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if cw.decided {
+		if cw.zw != nil {
+			return cw.zw.Write(b)
+		}
+		return cw.ResponseWriter.Write(b)
+	}
+
+	cw.buf.Write(b)
+	if cw.buf.Len() >= cw.minSize {
+		if err := cw.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+// Flush implements http.Flusher, compressing the response if buffered, i.e., to stream it.
+func (cw *responseWriter) Flush() {
+	if !cw.decided {
+		if cw.code == 0 {
+			cw.WriteHeader(http.StatusOK)
+		}
+		cw.decide(cw.buf.Len() > 0)
+	}
+	if zw, ok := cw.zw.(interface{ Flush() error }); ok {
+		zw.Flush()
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Close writes the response if it's still buffered, uncompressed as it's smaller than minSize,
+// or flushes the compressed data.
+func (cw *responseWriter) Close() error {
+	if !cw.decided {
+		if cw.code == 0 {
+			// Nothing was written, the server writes its default response.
+			return nil
+		}
+		return cw.decide(false)
+	}
+	if cw.zw != nil {
+		return cw.zw.Close()
+	}
+	return nil
+}
+
+// decide writes the status and the buffered bytes, compressed if compress.
+func (cw *responseWriter) decide(compress bool) error {
+	cw.decided = true
+	h := cw.Header()
+	if compress {
+		h.Set("Content-Encoding", cw.enc.Name)
+		h.Del("Content-Length")
+		if etag := h.Get("Etag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			h.Set("Etag", "W/"+etag)
+		}
+		cw.ResponseWriter.WriteHeader(cw.code)
+		cw.zw = cw.enc.NewWriter(cw.ResponseWriter)
+		_, err := cw.zw.Write(cw.buf.Bytes())
+		cw.buf.Reset()
+		return err
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.code)
+	if cw.buf.Len() == 0 {
+		return nil
+	}
+	_, err := cw.ResponseWriter.Write(cw.buf.Bytes())
+	cw.buf.Reset()
+	return err
+}
+
+// bodyAllowed reports whether a response with status code has a body.
+func bodyAllowed(code int) bool {
+	return code >= 200 && code != http.StatusNoContent && code != http.StatusNotModified
+}
@@ -0,0 +1,180 @@
+package compress
+
+import (
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestNegotiate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc   string
+		accept string
+		want   string
+		wantOk bool
+	}{
+		{
+			desc:   "prefers the first offered encoding among equally accepted ones",
+			accept: "gzip, deflate, br",
+			want:   "br",
+			wantOk: true,
+		},
+		{
+			desc:   "honors q-values",
+			accept: "br;q=0.5, gzip",
+			want:   "gzip",
+			wantOk: true,
+		},
+		{
+			desc:   "falls back to gzip for clients not accepting brotli",
+			accept: "gzip, deflate",
+			want:   "gzip",
+			wantOk: true,
+		},
+		{
+			desc:   "accepts any encoding with a wildcard",
+			accept: "br;q=0, *",
+			want:   "gzip",
+			wantOk: true,
+		},
+		{
+			desc:   "refuses encodings with a zero q-value",
+			accept: "gzip;q=0, br;q=0",
+		},
+		{
+			desc:   "refuses unknown encodings",
+			accept: "deflate",
+		},
+		{
+			desc: "refuses without Accept-Encoding",
+		},
+	}
+
+	for _, test := range tests {
+		got, ok := Negotiate(test.accept, Encodings)
+		if ok != test.wantOk || got.Name != test.want {
+			t.Errorf("%s: Negotiate(%q, _): want (%q, %v), got (%q, %v)", test.desc, test.accept, test.want, test.wantOk, got.Name, ok)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	large := strings.Repeat(`{"title":"some-title"}`, 100)
+
+	tests := []struct {
+		desc         string
+		method       string
+		accept       string
+		code         int
+		body         string
+		wantEncoding string
+		wantETag     string
+	}{
+		{
+			desc:         "compresses large responses with brotli if accepted, weakening their ETag",
+			method:       http.MethodGet,
+			accept:       "gzip, deflate, br",
+			code:         http.StatusOK,
+			body:         large,
+			wantEncoding: "br",
+			wantETag:     `W/"some-etag"`,
+		},
+		{
+			desc:         "compresses large responses with gzip otherwise",
+			method:       http.MethodGet,
+			accept:       "gzip",
+			code:         http.StatusOK,
+			body:         large,
+			wantEncoding: "gzip",
+			wantETag:     `W/"some-etag"`,
+		},
+		{
+			desc:     "writes small responses as is",
+			method:   http.MethodGet,
+			accept:   "gzip",
+			code:     http.StatusOK,
+			body:     `{"count":0}`,
+			wantETag: `"some-etag"`,
+		},
+		{
+			desc:     "writes responses as is for clients not accepting gzip",
+			method:   http.MethodGet,
+			code:     http.StatusOK,
+			body:     large,
+			wantETag: `"some-etag"`,
+		},
+		{
+			desc:     "writes responses without body as is",
+			method:   http.MethodGet,
+			accept:   "gzip",
+			code:     http.StatusNotModified,
+			wantETag: `"some-etag"`,
+		},
+	}
+
+	for _, test := range tests {
+		h := Handler(1024, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Etag", `"some-etag"`)
+			w.WriteHeader(test.code)
+			// Responses are written in chunks, as they're encoded.
+			for i := 0; i < len(test.body); i += 100 {
+				end := i + 100
+				if end > len(test.body) {
+					end = len(test.body)
+				}
+				w.Write([]byte(test.body[i:end]))
+			}
+		}))
+
+		req := httptest.NewRequest(test.method, "/api/list", nil)
+		if test.accept != "" {
+			req.Header.Set("Accept-Encoding", test.accept)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		if w.Code != test.code {
+			t.Errorf("%s: Handler(_, _): want status %d, got %d", test.desc, test.code, w.Code)
+		}
+		if got := w.Header().Get("Content-Encoding"); got != test.wantEncoding {
+			t.Errorf("%s: Handler(_, _): want Content-Encoding %q, got %q", test.desc, test.wantEncoding, got)
+		}
+		if got := w.Header().Get("Etag"); got != test.wantETag {
+			t.Errorf("%s: Handler(_, _): want ETag %q, got %q", test.desc, test.wantETag, got)
+		}
+		if got := w.Header().Get("Vary"); got != "Accept-Encoding" {
+			t.Errorf("%s: Handler(_, _): want Vary Accept-Encoding, got %q", test.desc, got)
+		}
+
+		var r io.Reader = w.Body
+		switch test.wantEncoding {
+		case "br":
+			r = brotli.NewReader(w.Body)
+		case "gzip":
+			zr, err := gzip.NewReader(w.Body)
+			if err != nil {
+				t.Fatalf("%s: Handler(_, _): want a gzipped body, got %v", test.desc, err)
+			}
+			r = zr
+		}
+		b, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatalf("%s: Handler(_, _): error decompressing body: %v", test.desc, err)
+		}
+		body := string(b)
+		if diff := pretty.Compare(body, test.body); diff != "" {
+			t.Errorf("%s: Handler(_, _) diff: (-got +want)\n%s", test.desc, diff)
+		}
+	}
+}
@@ -0,0 +1,347 @@
+// Package config contains the configuration of the server and its commands.
+//
+// A Config starts from Default, then is overridden in order by a JSON file, the env variables
+// and the command-line flags, see Load. Each setting names its env variable and flag, if any,
+// with `env` and `flag` struct tags. Secrets have no flag, so that they don't leak into process listings.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/riacataquian/news/internal/ratelimit"
+)
+
+// ErrInvalidConfig is the error message for a configuration that fails validation.
+var ErrInvalidConfig = errors.New("invalid configuration")
+
+// Config is the configuration of the server and its commands.
+type Config struct {
+	Server    Server    `json:"server"`
+	Database  Database  `json:"database"`
+	NewsAPI   NewsAPI   `json:"newsapi"`
+	Cron      Cron      `json:"cron"`
+	RateLimit RateLimit `json:"rateLimit"`
+	Secrets   Secrets   `json:"secrets"`
+	Tracing   Tracing   `json:"tracing"`
+	CORS      CORS      `json:"cors"`
+}
+
+// Server configures how the HTTP server listens and for how long it waits on clients.
+type Server struct {
+	// Addr is the TCP address to listen on, i.e., :8000.
+	Addr string `json:"addr" env:"ADDR" flag:"addr"`
+	// ReadTimeout, WriteTimeout and IdleTimeout bound the time spent reading a request,
+	// writing its response and waiting for the next request of a kept-alive connection.
+	ReadTimeout  Duration `json:"readTimeout" env:"READ_TIMEOUT" flag:"read-timeout"`
+	WriteTimeout Duration `json:"writeTimeout" env:"WRITE_TIMEOUT" flag:"write-timeout"`
+	IdleTimeout  Duration `json:"idleTimeout" env:"IDLE_TIMEOUT" flag:"idle-timeout"`
+	// ShutdownTimeout is how long in-flight requests are given to finish once the server is stopping.
+	ShutdownTimeout Duration `json:"shutdownTimeout" env:"SHUTDOWN_TIMEOUT" flag:"shutdown-timeout"`
+	// TLSCertFile and TLSKeyFile serve HTTPS when both are set.
+	TLSCertFile string `json:"tlsCertFile" env:"TLS_CERT_FILE" flag:"tls-cert-file"`
+	TLSKeyFile  string `json:"tlsKeyFile" env:"TLS_KEY_FILE" flag:"tls-key-file"`
+	// HTTP2 enables HTTP/2 when serving HTTPS.
+	HTTP2 bool `json:"http2" env:"HTTP2" flag:"http2"`
+	// CompressMinSize is the size, in bytes, from which responses are compressed for clients accepting it.
+	CompressMinSize int `json:"compressMinSize" env:"COMPRESS_MIN_SIZE" flag:"compress-min-size"`
+	// UnversionedSunset is the date the unversioned /api routes, deprecated in favor of /api/v1, stop being served,
+	// i.e., 2027-04-18. Empty until it's planned.
+	UnversionedSunset string `json:"unversionedSunset" env:"UNVERSIONED_SUNSET" flag:"unversioned-sunset"`
+}
+
+// UnversionedSunsetDate returns the UnversionedSunset date, zero if unset.
+func (s Server) UnversionedSunsetDate() time.Time {
+	t, _ := time.Parse("2006-01-02", s.UnversionedSunset)
+	return t
+}
+
+// Database configures the connections to the Postgresql database.
+type Database struct {
+	Host string `json:"host" env:"DB_HOST" flag:"db-host"`
+	Port int    `json:"port" env:"DB_PORT" flag:"db-port"`
+	Name string `json:"name" env:"DB_NAME" flag:"db-name"`
+	User string `json:"user" env:"DB_USER" flag:"db-user"`
+	// Password is read from the file at secrets.dbPasswordFile, if set.
+	Password Secret `json:"password" env:"DB_PASSWORD"`
+}
+
+// NewsAPI configures the requests to newsapi.
+type NewsAPI struct {
+	// Keys are the keys requests rotate among, read from the file at secrets.apiKeysFile, if set.
+	// The env variable separates them by commas or new lines.
+	Keys []Secret `json:"keys" env:"API_KEYS"`
+	// Key is a single key, used when Keys is empty. It's read from the file at secrets.apiKeyFile, if set.
+	Key Secret `json:"key" env:"API_KEY"`
+	// Cooldown is how long a key is skipped once it's exhausted or rate limited.
+	Cooldown Duration `json:"cooldown" env:"API_KEY_COOLDOWN" flag:"api-key-cooldown"`
+	// Timeout bounds each request to newsapi.
+	Timeout Duration `json:"timeout" env:"NEWSAPI_TIMEOUT" flag:"newsapi-timeout"`
+	// MaxResults is how many results of a query newsapi's plan lets us page through, i.e., 100 for the developer plan.
+	// Zero is unlimited.
+	MaxResults int `json:"maxResults" env:"NEWSAPI_MAX_RESULTS" flag:"newsapi-max-results"`
+	// ListURL and HeadlinesURL are the URLs of newsapi's everything and top-headlines endpoints.
+	ListURL      string `json:"listUrl" env:"NEWSAPI_LIST_URL" flag:"newsapi-list-url"`
+	HeadlinesURL string `json:"headlinesUrl" env:"NEWSAPI_HEADLINES_URL" flag:"newsapi-headlines-url"`
+}
+
+// APIKeys returns the Keys, or the single Key if there's none.
+func (n NewsAPI) APIKeys() []string {
+	var keys []string
+	for _, k := range n.Keys {
+		if strings.TrimSpace(string(k)) != "" {
+			keys = append(keys, string(k))
+		}
+	}
+	if len(keys) == 0 && n.Key != "" {
+		keys = append(keys, string(n.Key))
+	}
+	return keys
+}
+
+// Cron configures the cron jobs.
+type Cron struct {
+	// Interval is how often the list job fetches news as per the Watchlist.
+	Interval Duration `json:"interval" env:"CRON_INTERVAL" flag:"cron-interval"`
+	// Language is the language of the fetched news, i.e., en.
+	Language string `json:"language" env:"CRON_LANGUAGE" flag:"cron-language"`
+	// Watchlist are the values fetched per request parameter.
+	Watchlist []Watch `json:"watchlist"`
+}
+
+// Watch are the values of a request parameter the cron jobs fetch news for.
+type Watch struct {
+	// Key is the request parameter, one of domains, sources or query.
+	Key    string   `json:"key"`
+	Values []string `json:"values"`
+}
+
+// RateLimit configures the rate limits of the client keys.
+type RateLimit struct {
+	// Tiers are formatted as per ratelimit.ParseTiers, i.e., default=60/10000;partner=600/100000.
+	Tiers string `json:"tiers" env:"RATE_LIMIT_TIERS" flag:"rate-limit-tiers"`
+	// Store is where requests are counted: postgres, or memory for a single instance.
+	Store string `json:"store" env:"RATE_LIMIT_STORE" flag:"rate-limit-store"`
+}
+
+// Secrets configures the files secrets are read from, and their reloading.
+type Secrets struct {
+	// APIKeysFile, APIKeyFile and DBPasswordFile are the files newsapi.keys, newsapi.key and database.password
+	// are read from instead, i.e., mounted Docker or Kubernetes secrets, so that they don't leak into process listings.
+	APIKeysFile    string `json:"apiKeysFile" env:"API_KEYS_FILE" flag:"api-keys-file"`
+	APIKeyFile     string `json:"apiKeyFile" env:"API_KEY_FILE" flag:"api-key-file"`
+	DBPasswordFile string `json:"dbPasswordFile" env:"DB_PASSWORD_FILE" flag:"db-password-file"`
+	// Interval is how often the secret files are checked for changes.
+	Interval Duration `json:"interval" env:"SECRETS_INTERVAL" flag:"secrets-interval"`
+}
+
+// Files returns the files secrets are read from, by the path of their setting, i.e., newsapi.keys.
+func (s Secrets) Files() map[string]string {
+	return map[string]string{
+		"newsapi.keys":      s.APIKeysFile,
+		"newsapi.key":       s.APIKeyFile,
+		"database.password": s.DBPasswordFile,
+	}
+}
+
+// Tracing configures the export of the spans of requests, newsapi calls and inserts.
+type Tracing struct {
+	// Exporter is where spans are exported: stdout as JSON lines, or none.
+	Exporter string `json:"exporter" env:"TRACE_EXPORTER" flag:"trace-exporter"`
+}
+
+// CORS configures the cross-origin requests of browser clients to /api.
+type CORS struct {
+	// AllowedOrigins are the origins allowed to call /api, i.e., https://news.example.com, or * for any.
+	// None disables CORS.
+	AllowedOrigins []string `json:"allowedOrigins" env:"CORS_ALLOWED_ORIGINS" flag:"cors-allowed-origins"`
+	AllowedMethods []string `json:"allowedMethods" env:"CORS_ALLOWED_METHODS" flag:"cors-allowed-methods"`
+	// AllowedHeaders are the request headers allowed, or * for any.
+	AllowedHeaders []string `json:"allowedHeaders" env:"CORS_ALLOWED_HEADERS" flag:"cors-allowed-headers"`
+	// ExposedHeaders are the response headers readable by browser clients besides the CORS-safelisted ones.
+	ExposedHeaders []string `json:"exposedHeaders" env:"CORS_EXPOSED_HEADERS" flag:"cors-exposed-headers"`
+	// AllowCredentials allows requests with cookies or TLS client certificates. It's invalid with any origin.
+	AllowCredentials bool `json:"allowCredentials" env:"CORS_ALLOW_CREDENTIALS" flag:"cors-allow-credentials"`
+	// MaxAge is how long browsers cache the response to a preflight request.
+	MaxAge Duration `json:"maxAge" env:"CORS_MAX_AGE" flag:"cors-max-age"`
+}
+
+// Default returns the configuration used unless overridden.
+func Default() *Config {
+	return &Config{
+		Server: Server{
+			Addr:            ":8000",
+			ReadTimeout:     Duration{10 * time.Second},
+			WriteTimeout:    Duration{30 * time.Second},
+			IdleTimeout:     Duration{2 * time.Minute},
+			ShutdownTimeout: Duration{30 * time.Second},
+			HTTP2:           true,
+			CompressMinSize: 1024,
+		},
+		Database: Database{
+			Host: "localhost",
+			Port: 5432,
+			Name: "news",
+		},
+		NewsAPI: NewsAPI{
+			Cooldown:     Duration{time.Hour},
+			Timeout:      Duration{5 * time.Second},
+			MaxResults:   100,
+			ListURL:      "https://newsapi.org/v2/everything",
+			HeadlinesURL: "https://newsapi.org/v2/top-headlines",
+		},
+		Cron: Cron{
+			Interval: Duration{time.Hour},
+			Language: "en",
+			Watchlist: []Watch{
+				{Key: "domains", Values: []string{"techcrunch.com", "nytimes.com", "wsj.com"}},
+				{Key: "sources", Values: []string{"bloomberg", "financial-times", "the-wall-street-journal"}},
+				{Key: "query", Values: []string{"bitcoin", "ethereum", "blockchain"}},
+			},
+		},
+		RateLimit: RateLimit{
+			Store: "postgres",
+		},
+		Secrets: Secrets{
+			Interval: Duration{30 * time.Second},
+		},
+		Tracing: Tracing{
+			Exporter: "none",
+		},
+		CORS: CORS{
+			AllowedMethods: []string{"GET", "POST"},
+			AllowedHeaders: []string{"Authorization", "Content-Type", "X-Request-ID"},
+			ExposedHeaders: []string{"X-Request-ID", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "Retry-After", "Link", "Deprecation", "Sunset"},
+			MaxAge:         Duration{10 * time.Minute},
+		},
+	}
+}
+
+// Validate returns an ErrInvalidConfig listing every invalid setting, if any.
+func (c *Config) Validate() error {
+	var problems []string
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+
+	check(c.Server.Addr != "", "server.addr is required")
+	check(c.Server.ReadTimeout.Duration >= 0, "server.readTimeout must not be negative")
+	check(c.Server.WriteTimeout.Duration >= 0, "server.writeTimeout must not be negative")
+	check(c.Server.IdleTimeout.Duration >= 0, "server.idleTimeout must not be negative")
+	check(c.Server.ShutdownTimeout.Duration > 0, "server.shutdownTimeout must be positive")
+	check((c.Server.TLSCertFile == "") == (c.Server.TLSKeyFile == ""), "server.tlsCertFile and server.tlsKeyFile must be set together")
+	check(c.Server.CompressMinSize >= 0, "server.compressMinSize must not be negative")
+	check(c.Server.UnversionedSunset == "" || !c.Server.UnversionedSunsetDate().IsZero(), "server.unversionedSunset must be a date, i.e., 2027-04-18")
+
+	check(c.Database.Host != "", "database.host is required")
+	check(c.Database.Port > 0 && c.Database.Port < 1<<16, "database.port must be between 1 and 65535")
+	check(c.Database.Name != "", "database.name is required")
+
+	check(c.NewsAPI.Cooldown.Duration > 0, "newsapi.cooldown must be positive")
+	check(c.NewsAPI.Timeout.Duration > 0, "newsapi.timeout must be positive")
+	check(c.NewsAPI.MaxResults >= 0, "newsapi.maxResults must not be negative")
+	check(isURL(c.NewsAPI.ListURL), "newsapi.listUrl must be an absolute URL")
+	check(isURL(c.NewsAPI.HeadlinesURL), "newsapi.headlinesUrl must be an absolute URL")
+
+	check(c.Cron.Interval.Duration > 0, "cron.interval must be positive")
+	check(c.Cron.Language != "", "cron.language is required")
+	for i, w := range c.Cron.Watchlist {
+		check(w.Key == "domains" || w.Key == "sources" || w.Key == "query", "cron.watchlist[%d].key must be one of domains, sources or query", i)
+	}
+
+	_, err := ratelimit.ParseTiers(c.RateLimit.Tiers)
+	check(err == nil, "rateLimit.tiers: %v", err)
+	check(c.RateLimit.Store == "postgres" || c.RateLimit.Store == "memory", "rateLimit.store must be one of postgres or memory")
+
+	check(isFile(c.Secrets.APIKeysFile), "secrets.apiKeysFile must be a file")
+	check(isFile(c.Secrets.APIKeyFile), "secrets.apiKeyFile must be a file")
+	check(isFile(c.Secrets.DBPasswordFile), "secrets.dbPasswordFile must be a file")
+	check(c.Secrets.Interval.Duration > 0, "secrets.interval must be positive")
+
+	check(c.Tracing.Exporter == "none" || c.Tracing.Exporter == "stdout", "tracing.exporter must be one of none or stdout")
+
+	for i, o := range c.CORS.AllowedOrigins {
+		check(o == "*" || isURL(o), "cors.allowedOrigins[%d] must be * or an origin, i.e., https://news.example.com", i)
+		check(o != "*" || !c.CORS.AllowCredentials, "cors.allowedOrigins must not allow any origin with cors.allowCredentials")
+	}
+	check(c.CORS.MaxAge.Duration >= 0, "cors.maxAge must not be negative")
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidConfig, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// String returns the configuration as indented JSON, with its secrets redacted.
+func (c *Config) String() string {
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("error marshalling configuration: %v", err)
+	}
+	return string(b)
+}
+
+// isURL reports whether s is an absolute URL.
+func isURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
+
+// isFile reports whether the file at path, if set, exists and isn't a directory.
+func isFile(path string) bool {
+	if path == "" {
+		return true
+	}
+	fi, err := os.Stat(path)
+	return err == nil && !fi.IsDir()
+}
+
+// Duration is a time.Duration formatted as a string in JSON, i.e., "30s".
+type Duration struct {
+	time.Duration
+}
+
+// MarshalJSON implements json.Marshaler.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration should be a string, i.e., \"30s\": %v", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	d.Duration = v
+	return nil
+}
+
+// redacted replaces the value of a set Secret when printed.
+const redacted = "REDACTED"
+
+// Secret is a setting that is redacted when printed or marshalled.
+type Secret string
+
+// String implements fmt.Stringer.
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+// MarshalJSON implements json.Marshaler.
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
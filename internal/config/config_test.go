@@ -0,0 +1,156 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		desc    string
+		modify  func(*Config)
+		wantErr string
+	}{
+		{
+			desc:   "accepts the default configuration",
+			modify: func(*Config) {},
+		},
+		{
+			desc: "rejects a TLS certificate without its key",
+			modify: func(c *Config) {
+				c.Server.TLSCertFile = "cert.pem"
+			},
+			wantErr: "server.tlsCertFile and server.tlsKeyFile must be set together",
+		},
+		{
+			desc: "rejects a sunset that isn't a date",
+			modify: func(c *Config) {
+				c.Server.UnversionedSunset = "next year"
+			},
+			wantErr: "server.unversionedSunset must be a date, i.e., 2027-04-18",
+		},
+		{
+			desc: "rejects an invalid port and newsapi URL",
+			modify: func(c *Config) {
+				c.Database.Port = 0
+				c.NewsAPI.ListURL = "/v2/everything"
+			},
+			wantErr: "database.port must be between 1 and 65535; newsapi.listUrl must be an absolute URL",
+		},
+		{
+			desc: "rejects unknown watchlist keys, rate limit tiers and stores",
+			modify: func(c *Config) {
+				c.Cron.Watchlist = []Watch{{Key: "country"}}
+				c.RateLimit.Tiers = "partner=lots"
+				c.RateLimit.Store = "redis"
+			},
+			wantErr: "cron.watchlist[0].key must be one of domains, sources or query",
+		},
+		{
+			desc: "rejects a missing secret file",
+			modify: func(c *Config) {
+				c.Secrets.DBPasswordFile = "/missing/password"
+			},
+			wantErr: "secrets.dbPasswordFile must be a file",
+		},
+		{
+			desc: "rejects an unknown trace exporter",
+			modify: func(c *Config) {
+				c.Tracing.Exporter = "jaeger"
+			},
+			wantErr: "tracing.exporter must be one of none or stdout",
+		},
+		{
+			desc: "rejects credentials for any origin",
+			modify: func(c *Config) {
+				c.CORS.AllowedOrigins = []string{"https://news.example.com", "*"}
+				c.CORS.AllowCredentials = true
+			},
+			wantErr: "cors.allowedOrigins must not allow any origin with cors.allowCredentials",
+		},
+	}
+
+	for _, test := range tests {
+		conf := Default()
+		test.modify(conf)
+
+		err := conf.Validate()
+		if test.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: Validate(): want nil, got %v", test.desc, err)
+			}
+			continue
+		}
+		if !errors.Is(err, ErrInvalidConfig) || !strings.Contains(err.Error(), test.wantErr) {
+			t.Errorf("%s: Validate(): want ErrInvalidConfig containing %q, got %v", test.desc, test.wantErr, err)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	conf := Default()
+	conf.Database.Password = "some-password"
+	conf.NewsAPI.Keys = []Secret{"some-key-1", "some-key-2"}
+
+	got := conf.String()
+	for _, secret := range []string{"some-password", "some-key-1", "some-key-2"} {
+		if strings.Contains(got, secret) {
+			t.Errorf("String(): want %q redacted, got %s", secret, got)
+		}
+	}
+	if !strings.Contains(got, `"password": "REDACTED"`) {
+		t.Errorf("String(): want the password marked as redacted, got %s", got)
+	}
+	if !strings.Contains(got, `"readTimeout": "10s"`) {
+		t.Errorf("String(): want durations formatted as strings, got %s", got)
+	}
+}
+
+func TestDuration(t *testing.T) {
+	var got struct {
+		Timeout Duration `json:"timeout"`
+	}
+	if err := json.Unmarshal([]byte(`{"timeout": "1m30s"}`), &got); err != nil {
+		t.Fatalf("UnmarshalJSON(): want nil, got %v", err)
+	}
+	if diff := pretty.Compare(got.Timeout.Duration, 90*time.Second); diff != "" {
+		t.Errorf("UnmarshalJSON() diff: (-got +want)\n%s", diff)
+	}
+
+	if err := json.Unmarshal([]byte(`{"timeout": 90}`), &got); err == nil {
+		t.Error("UnmarshalJSON(): want an error for a duration that isn't a string, got nil")
+	}
+}
+
+func TestAPIKeys(t *testing.T) {
+	tests := []struct {
+		desc string
+		in   NewsAPI
+		want []string
+	}{
+		{
+			desc: "returns the keys, skipping empty ones",
+			in:   NewsAPI{Keys: []Secret{"key-1", " ", "key-2"}, Key: "key-3"},
+			want: []string{"key-1", "key-2"},
+		},
+		{
+			desc: "returns the single key when there's no keys",
+			in:   NewsAPI{Key: "key-3"},
+			want: []string{"key-3"},
+		},
+		{
+			desc: "returns nothing when no key is configured",
+		},
+	}
+
+	for _, test := range tests {
+		if diff := pretty.Compare(test.in.APIKeys(), test.want); diff != "" {
+			t.Errorf("%s: APIKeys() diff: (-got +want)\n%s", test.desc, diff)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package config
+
+// This file contains the loading of a Config from a file, the env variables and the flags.
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(Duration{})
+
+// Load returns the Default configuration overridden, in order, by:
+// the JSON file at the -config flag or at the env variable CONFIG_FILE, if any;
+// the env variables; and the flags set in args.
+// Secrets are then read from their files, if set, see Secrets.
+//
+// The flags are defined on fs then parsed from args, fs can define its own flags beforehand.
+// It returns an ErrInvalidConfig if the resulting configuration fails validation.
+func Load(fs *flag.FlagSet, args []string) (*Config, error) {
+	path := fs.String("config", os.Getenv("CONFIG_FILE"), "path to a JSON configuration file")
+	settings := settingsOf(reflect.ValueOf(Default()).Elem(), "")
+	flags := make(map[string]*string)
+	for _, s := range settings {
+		if s.flag != "" {
+			flags[s.flag] = fs.String(s.flag, "", fmt.Sprintf("overrides %s, see env %s", s.path, s.env))
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	conf := Default()
+	if *path != "" {
+		if err := loadFile(conf, *path); err != nil {
+			return nil, err
+		}
+	}
+
+	// Settings are looked up on conf, now that the file may have replaced their slices.
+	settings = settingsOf(reflect.ValueOf(conf).Elem(), "")
+	for _, s := range settings {
+		if err := s.loadEnv(); err != nil {
+			return nil, err
+		}
+	}
+
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	for _, s := range settings {
+		if !set[s.flag] {
+			continue
+		}
+		if err := s.set(*flags[s.flag]); err != nil {
+			return nil, fmt.Errorf("invalid -%s: %v", s.flag, err)
+		}
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+	if err := readSecretFiles(settings, conf.Secrets.Files()); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+// readSecretFiles overrides the settings with the content of their files, by path, if set.
+// Surrounding whitespace, i.e., a trailing new line, is trimmed.
+func readSecretFiles(settings []setting, files map[string]string) error {
+	for _, s := range settings {
+		path := files[s.path]
+		if path == "" {
+			continue
+		}
+
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading %s file: %v", s.path, err)
+		}
+		if err := s.set(strings.TrimSpace(string(b))); err != nil {
+			return fmt.Errorf("invalid %s file: %v", s.path, err)
+		}
+	}
+	return nil
+}
+
+// loadFile overrides conf with the JSON file at path. Unknown settings are errors, i.e., typos.
+func loadFile(conf *Config, path string) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading configuration file: %v", err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(conf); err != nil {
+		return fmt.Errorf("decoding configuration file %s: %v", path, err)
+	}
+	return nil
+}
+
+// setting is a field of a Config that's overridable by an env variable or a flag.
+type setting struct {
+	// path is the field's JSON path, i.e., server.addr.
+	path  string
+	env   string
+	flag  string
+	value reflect.Value
+}
+
+// settingsOf returns the settings of the struct v, recursively, prefixing their paths with prefix.
+func settingsOf(v reflect.Value, prefix string) []setting {
+	var settings []setting
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		path := prefix + strings.Split(f.Tag.Get("json"), ",")[0]
+
+		if f.Type.Kind() == reflect.Struct && f.Type != durationType {
+			settings = append(settings, settingsOf(v.Field(i), path+".")...)
+			continue
+		}
+
+		env, flg := f.Tag.Get("env"), f.Tag.Get("flag")
+		if env == "" && flg == "" {
+			continue
+		}
+		settings = append(settings, setting{path: path, env: env, flag: flg, value: v.Field(i)})
+	}
+	return settings
+}
+
+// loadEnv overrides the setting with its env variable, if set.
+func (s setting) loadEnv() error {
+	if s.env == "" {
+		return nil
+	}
+
+	v, ok := os.LookupEnv(s.env)
+	if !ok {
+		return nil
+	}
+	if err := s.set(v); err != nil {
+		return fmt.Errorf("invalid %s: %v", s.env, err)
+	}
+	return nil
+}
+
+// set parses v as per the setting's type then sets it.
+// Slices are separated by commas or new lines.
+func (s setting) set(v string) error {
+	switch t := s.value.Type(); {
+	case t == durationType:
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		s.value.Set(reflect.ValueOf(Duration{d}))
+	case t.Kind() == reflect.String:
+		s.value.SetString(v)
+	case t.Kind() == reflect.Int:
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		s.value.SetInt(int64(n))
+	case t.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return err
+		}
+		s.value.SetBool(b)
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.String:
+		items := strings.FieldsFunc(v, func(r rune) bool {
+			return r == ',' || r == '\n' || r == '\r'
+		})
+		slice := reflect.MakeSlice(t, 0, len(items))
+		for _, item := range items {
+			if item = strings.TrimSpace(item); item != "" {
+				slice = reflect.Append(slice, reflect.ValueOf(item).Convert(t.Elem()))
+			}
+		}
+		s.value.Set(slice)
+	default:
+		return fmt.Errorf("unsupported setting type %s", t)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+// writeFile writes content to a file named name in dir then returns its path.
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := writeFile(t, dir, "config.json", `{
+		"server": {"addr": ":9000", "readTimeout": "5s"},
+		"database": {"host": "db.internal", "name": "news-file"},
+		"cron": {"watchlist": [{"key": "query", "values": ["golang"]}]}
+	}`)
+	keys := writeFile(t, dir, "keys", "file-key-1\nfile-key-2,file-key-3\n")
+	password := writeFile(t, dir, "password", "file-password\n")
+
+	os.Clearenv()
+	defer os.Clearenv()
+	os.Setenv("CONFIG_FILE", file)
+	os.Setenv("DB_NAME", "news-env")
+	os.Setenv("DB_PASSWORD", "some-password")
+	os.Setenv("API_KEYS_FILE", keys)
+	os.Setenv("API_KEYS", "env-key")
+	os.Setenv("IDLE_TIMEOUT", "1m")
+
+	got, err := Load(flag.NewFlagSet("test", flag.ContinueOnError), []string{"-addr", ":9090", "-http2=false", "-db-password-file", password})
+	if err != nil {
+		t.Fatalf("Load(_, _): want (_, nil), got (_, %v)", err)
+	}
+
+	want := Default()
+	want.Server.Addr = ":9090"
+	want.Server.ReadTimeout = Duration{5 * time.Second}
+	want.Server.IdleTimeout = Duration{time.Minute}
+	want.Server.HTTP2 = false
+	want.Database.Host = "db.internal"
+	want.Database.Name = "news-env"
+	want.Database.Password = "file-password"
+	want.NewsAPI.Keys = []Secret{"file-key-1", "file-key-2", "file-key-3"}
+	want.Cron.Watchlist = []Watch{{Key: "query", Values: []string{"golang"}}}
+	want.Secrets.APIKeysFile = keys
+	want.Secrets.DBPasswordFile = password
+	if diff := pretty.Compare(got, want); diff != "" {
+		t.Errorf("Load(_, _) diff: (-got +want)\n%s", diff)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		desc string
+		env  map[string]string
+		args []string
+	}{
+		{
+			desc: "unknown setting in the file",
+			args: []string{"-config", writeFile(t, dir, "typo.json", `{"server": {"adress": ":9000"}}`)},
+		},
+		{
+			desc: "missing file",
+			args: []string{"-config", filepath.Join(dir, "missing.json")},
+		},
+		{
+			desc: "malformed env variable",
+			env:  map[string]string{"DB_PORT": "five"},
+		},
+		{
+			desc: "malformed flag",
+			args: []string{"-read-timeout", "soon"},
+		},
+		{
+			desc: "missing secret file",
+			env:  map[string]string{"API_KEYS_FILE": filepath.Join(dir, "missing")},
+		},
+		{
+			desc: "invalid configuration",
+			args: []string{"-rate-limit-store", "redis"},
+		},
+	}
+
+	for _, test := range tests {
+		os.Clearenv()
+		for k, v := range test.env {
+			os.Setenv(k, v)
+		}
+
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(ioutil.Discard)
+		if got, err := Load(fs, test.args); err == nil {
+			t.Errorf("%s: Load(_, %v): want (nil, error), got (%v, nil)", test.desc, test.args, got)
+		}
+	}
+	os.Clearenv()
+}
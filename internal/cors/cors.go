@@ -0,0 +1,154 @@
+// Package cors serves the cross-origin requests of browser clients,
+// see https://developer.mozilla.org/en-US/docs/Web/HTTP/CORS.
+package cors
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/riacataquian/news/internal/config"
+)
+
+// Policy is the CORS policy of a handler, see Policy.Middleware.
+type Policy struct {
+	conf      config.CORS
+	anyOrigin bool
+	anyHeader bool
+	origins   map[string]bool
+	methods   map[string]bool
+	headers   map[string]bool
+	allowed   string
+	exposed   string
+	maxAge    string
+}
+
+// New returns the Policy configured by conf. A Policy allowing no origin is a no-op.
+func New(conf config.CORS) *Policy {
+	p := &Policy{
+		conf:    conf,
+		origins: make(map[string]bool),
+		methods: make(map[string]bool),
+		headers: make(map[string]bool),
+		allowed: strings.Join(conf.AllowedMethods, ", "),
+		exposed: strings.Join(conf.ExposedHeaders, ", "),
+	}
+	for _, o := range conf.AllowedOrigins {
+		if o == "*" {
+			p.anyOrigin = true
+		}
+		p.origins[strings.ToLower(o)] = true
+	}
+	for _, m := range conf.AllowedMethods {
+		p.methods[strings.ToUpper(m)] = true
+	}
+	for _, h := range conf.AllowedHeaders {
+		if h == "*" {
+			p.anyHeader = true
+		}
+		p.headers[http.CanonicalHeaderKey(h)] = true
+	}
+	if conf.MaxAge.Duration > 0 {
+		p.maxAge = strconv.Itoa(int(conf.MaxAge.Seconds()))
+	}
+	return p
+}
+
+// Middleware wraps h with the policy, i.e., as a mux.MiddlewareFunc:
+// it answers preflight requests, OPTIONS requests with an Access-Control-Request-Method header, with a 204
+// without calling h, then adds the CORS headers to the responses of h to the requests of allowed origins.
+//
+// The preflight requests of origins, methods or headers that aren't allowed are answered without CORS headers,
+// so that browsers block the request.
+func (p *Policy) Middleware(h http.Handler) http.Handler {
+	if len(p.origins) == 0 {
+		return h
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if !p.anyOrigin || p.conf.AllowCredentials {
+			// Responses differ per origin, caches mustn't serve them to another one.
+			w.Header().Add("Vary", "Origin")
+		}
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+			if origin != "" && p.allowsOrigin(origin) && p.allowsPreflight(r) {
+				p.setOrigin(w, origin)
+				w.Header().Set("Access-Control-Allow-Methods", p.allowed)
+				if headers := p.allowedHeaders(r); headers != "" {
+					w.Header().Set("Access-Control-Allow-Headers", headers)
+				}
+				if p.maxAge != "" {
+					w.Header().Set("Access-Control-Max-Age", p.maxAge)
+				}
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if origin != "" && p.allowsOrigin(origin) {
+			p.setOrigin(w, origin)
+			if p.exposed != "" {
+				w.Header().Set("Access-Control-Expose-Headers", p.exposed)
+			}
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// allowsOrigin reports whether the origin is allowed.
+func (p *Policy) allowsOrigin(origin string) bool {
+	return p.anyOrigin || p.origins[strings.ToLower(origin)]
+}
+
+// allowsPreflight reports whether the method and the headers requested by the preflight request r are allowed.
+func (p *Policy) allowsPreflight(r *http.Request) bool {
+	if !p.methods[strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))] {
+		return false
+	}
+	if p.anyHeader {
+		return true
+	}
+	for _, h := range requestedHeaders(r) {
+		if !p.headers[http.CanonicalHeaderKey(h)] {
+			return false
+		}
+	}
+	return true
+}
+
+// allowedHeaders returns the value of the Access-Control-Allow-Headers header answering the preflight request r:
+// the headers it requested when any header is allowed, the allowed headers otherwise.
+func (p *Policy) allowedHeaders(r *http.Request) string {
+	if p.anyHeader {
+		return strings.Join(requestedHeaders(r), ", ")
+	}
+	return strings.Join(p.conf.AllowedHeaders, ", ")
+}
+
+// setOrigin sets the Access-Control-Allow-Origin header of w, along with Access-Control-Allow-Credentials if allowed.
+// Any origin is answered with *, unless credentials are allowed which requires the origin itself.
+func (p *Policy) setOrigin(w http.ResponseWriter, origin string) {
+	if p.anyOrigin && !p.conf.AllowCredentials {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+	} else {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+	}
+	if p.conf.AllowCredentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// requestedHeaders returns the headers of the Access-Control-Request-Headers header of the preflight request r.
+func requestedHeaders(r *http.Request) []string {
+	var headers []string
+	for _, h := range strings.Split(r.Header.Get("Access-Control-Request-Headers"), ",") {
+		if h = strings.TrimSpace(h); h != "" {
+			headers = append(headers, h)
+		}
+	}
+	return headers
+}
@@ -0,0 +1,141 @@
+package cors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/riacataquian/news/internal/config"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	conf := config.CORS{
+		AllowedOrigins: []string{"https://news.example.com"},
+		AllowedMethods: []string{"GET", "POST"},
+		AllowedHeaders: []string{"Authorization", "Content-Type"},
+		ExposedHeaders: []string{"X-Request-ID"},
+		MaxAge:         config.Duration{Duration: 10 * time.Minute},
+	}
+	withCredentials := conf
+	withCredentials.AllowedOrigins = []string{"https://news.example.com", "https://admin.example.com"}
+	withCredentials.AllowCredentials = true
+	anyOrigin := conf
+	anyOrigin.AllowedOrigins = []string{"*"}
+	anyOrigin.AllowedHeaders = []string{"*"}
+
+	tests := []struct {
+		desc        string
+		conf        config.CORS
+		method      string
+		headers     map[string]string
+		wantCode    int
+		wantHeaders http.Header
+	}{
+		{
+			desc:     "answers preflight requests of allowed origins",
+			conf:     conf,
+			method:   http.MethodOptions,
+			headers:  map[string]string{"Origin": "https://news.example.com", "Access-Control-Request-Method": "GET", "Access-Control-Request-Headers": "authorization"},
+			wantCode: http.StatusNoContent,
+			wantHeaders: http.Header{
+				"Vary":                         {"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"},
+				"Access-Control-Allow-Origin":  {"https://news.example.com"},
+				"Access-Control-Allow-Methods": {"GET, POST"},
+				"Access-Control-Allow-Headers": {"Authorization, Content-Type"},
+				"Access-Control-Max-Age":       {"600"},
+			},
+		},
+		{
+			desc:     "answers preflight requests of unknown origins without CORS headers",
+			conf:     conf,
+			method:   http.MethodOptions,
+			headers:  map[string]string{"Origin": "https://evil.example.com", "Access-Control-Request-Method": "GET"},
+			wantCode: http.StatusNoContent,
+			wantHeaders: http.Header{
+				"Vary": {"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"},
+			},
+		},
+		{
+			desc:     "answers preflight requests of disallowed methods and headers without CORS headers",
+			conf:     conf,
+			method:   http.MethodOptions,
+			headers:  map[string]string{"Origin": "https://news.example.com", "Access-Control-Request-Method": "DELETE", "Access-Control-Request-Headers": "X-Custom"},
+			wantCode: http.StatusNoContent,
+			wantHeaders: http.Header{
+				"Vary": {"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"},
+			},
+		},
+		{
+			desc:     "adds CORS headers to the requests of allowed origins",
+			conf:     conf,
+			method:   http.MethodGet,
+			headers:  map[string]string{"Origin": "https://news.example.com"},
+			wantCode: http.StatusOK,
+			wantHeaders: http.Header{
+				"Vary":                          {"Origin"},
+				"Access-Control-Allow-Origin":   {"https://news.example.com"},
+				"Access-Control-Expose-Headers": {"X-Request-ID"},
+			},
+		},
+		{
+			desc:     "echoes the origin when allowing credentials",
+			conf:     withCredentials,
+			method:   http.MethodGet,
+			headers:  map[string]string{"Origin": "https://admin.example.com"},
+			wantCode: http.StatusOK,
+			wantHeaders: http.Header{
+				"Vary":                             {"Origin"},
+				"Access-Control-Allow-Origin":      {"https://admin.example.com"},
+				"Access-Control-Allow-Credentials": {"true"},
+				"Access-Control-Expose-Headers":    {"X-Request-ID"},
+			},
+		},
+		{
+			desc:     "allows any origin and the requested headers with a wildcard",
+			conf:     anyOrigin,
+			method:   http.MethodOptions,
+			headers:  map[string]string{"Origin": "https://other.example.com", "Access-Control-Request-Method": "POST", "Access-Control-Request-Headers": "X-Custom, Authorization"},
+			wantCode: http.StatusNoContent,
+			wantHeaders: http.Header{
+				"Vary":                         {"Access-Control-Request-Method", "Access-Control-Request-Headers"},
+				"Access-Control-Allow-Origin":  {"*"},
+				"Access-Control-Allow-Methods": {"GET, POST"},
+				"Access-Control-Allow-Headers": {"X-Custom, Authorization"},
+				"Access-Control-Max-Age":       {"600"},
+			},
+		},
+		{
+			desc:        "calls the handler without CORS when no origin is allowed",
+			conf:        config.CORS{},
+			method:      http.MethodOptions,
+			headers:     map[string]string{"Origin": "https://news.example.com", "Access-Control-Request-Method": "GET"},
+			wantCode:    http.StatusOK,
+			wantHeaders: http.Header{},
+		},
+	}
+
+	for _, test := range tests {
+		h := New(test.conf).Middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		req := httptest.NewRequest(test.method, "/api/list", nil)
+		for k, v := range test.headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		if w.Code != test.wantCode {
+			t.Errorf("%s: Middleware(_): want status %d, got %d", test.desc, test.wantCode, w.Code)
+		}
+		if diff := pretty.Compare(w.Header(), test.wantHeaders); diff != "" {
+			t.Errorf("%s: Middleware(_) diff: (-got +want)\n%s", test.desc, diff)
+		}
+	}
+}
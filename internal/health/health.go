@@ -0,0 +1,90 @@
+// Package health serves the liveness and readiness probes of the server, i.e., for an orchestrator.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Timeout bounds each readiness check.
+const Timeout = 2 * time.Second
+
+// Statuses of a probe and of its checks.
+const (
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable"
+)
+
+// Check reports whether a dependency of the server is ready, returning why it isn't.
+type Check struct {
+	Name string
+	Fn   func(context.Context) error
+}
+
+// Report is the response of a probe.
+type Report struct {
+	Status string `json:"status"`
+	// Checks are the status of each check, or why it failed, by name.
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// Live returns the liveness probe: it answers 200 as long as the server serves requests.
+func Live() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		write(w, http.StatusOK, Report{Status: StatusOK})
+	})
+}
+
+// Ready returns the readiness probe: it runs checks concurrently, each bounded by Timeout,
+// then answers 200 if they all pass, 503 otherwise, reporting each check.
+func Ready(checks ...Check) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := Run(r.Context(), checks...)
+		code := http.StatusOK
+		if report.Status != StatusOK {
+			code = http.StatusServiceUnavailable
+		}
+		write(w, code, report)
+	})
+}
+
+// Run runs checks concurrently, each bounded by Timeout, and reports their status.
+func Run(ctx context.Context, checks ...Check) Report {
+	report := Report{Status: StatusOK, Checks: make(map[string]string)}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, c := range checks {
+		wg.Add(1)
+		go func(c Check) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, Timeout)
+			defer cancel()
+			err := c.Fn(ctx)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				report.Status = StatusUnavailable
+				report.Checks[c.Name] = err.Error()
+				return
+			}
+			report.Checks[c.Name] = StatusOK
+		}(c)
+	}
+	wg.Wait()
+	return report
+}
+
+// write writes report as JSON with the status code.
+func write(w http.ResponseWriter, code int, report Report) {
+	w.Header().Set("content-type", "application/json")
+	w.Header().Set("cache-control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,65 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestLive(t *testing.T) {
+	t.Parallel()
+
+	w := httptest.NewRecorder()
+	Live().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Live(): want status %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestReady(t *testing.T) {
+	t.Parallel()
+
+	ok := Check{Name: "database", Fn: func(context.Context) error { return nil }}
+	failing := Check{Name: "apiKeys", Fn: func(context.Context) error { return errors.New("missing API key") }}
+
+	tests := []struct {
+		desc     string
+		checks   []Check
+		wantCode int
+		want     Report
+	}{
+		{
+			desc:     "answers 200 when every check passes",
+			checks:   []Check{ok},
+			wantCode: http.StatusOK,
+			want:     Report{Status: StatusOK, Checks: map[string]string{"database": StatusOK}},
+		},
+		{
+			desc:     "answers 503 listing the failing checks",
+			checks:   []Check{ok, failing},
+			wantCode: http.StatusServiceUnavailable,
+			want:     Report{Status: StatusUnavailable, Checks: map[string]string{"database": StatusOK, "apiKeys": "missing API key"}},
+		},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		Ready(test.checks...).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		if w.Code != test.wantCode {
+			t.Errorf("%s: Ready(_): want status %d, got %d", test.desc, test.wantCode, w.Code)
+		}
+
+		var got Report
+		if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatalf("%s: Ready(_): error decoding response: %v", test.desc, err)
+		}
+		if diff := pretty.Compare(got, test.want); diff != "" {
+			t.Errorf("%s: Ready(_) diff: (-got +want)\n%s", test.desc, diff)
+		}
+	}
+}
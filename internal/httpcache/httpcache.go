@@ -0,0 +1,62 @@
+// Package httpcache validates the responses cached by clients and CDNs with their ETag and Last-Modified date,
+// see https://tools.ietf.org/html/rfc7232.
+package httpcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ETag returns the strong ETag of a response's body: a hash of its bytes, quoted.
+func ETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// Conditional reports whether r is a conditional request, i.e., the client holds a cached response to validate.
+func Conditional(r *http.Request) bool {
+	return (r.Method == http.MethodGet || r.Method == http.MethodHead) &&
+		(r.Header.Get("If-None-Match") != "" || r.Header.Get("If-Modified-Since") != "")
+}
+
+// NotModified reports whether the client's cached response to r is fresh, i.e., to answer 304 Not Modified,
+// given the response's etag and its lastModified date, if not zero.
+//
+// If-None-Match takes precedence over If-Modified-Since, as per RFC 7232 section 6.
+// Only GET and HEAD requests are conditional.
+func NotModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if !Conditional(r) {
+		return false
+	}
+
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return matches(inm, etag)
+	}
+
+	if lastModified.IsZero() {
+		return false
+	}
+	ims, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	// HTTP dates have a precision of a second.
+	return !lastModified.Truncate(time.Second).After(ims)
+}
+
+// matches reports whether the If-None-Match header inm lists etag, or is *.
+// ETags are compared weakly, as per RFC 7232 section 3.2: W/"x" matches "x".
+func matches(inm, etag string) bool {
+	if strings.TrimSpace(inm) == "*" {
+		return true
+	}
+	for _, tag := range strings.Split(inm, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package httpcache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestETag(t *testing.T) {
+	t.Parallel()
+
+	a, b := ETag([]byte(`{"count":1}`)), ETag([]byte(`{"count":2}`))
+	if a == b {
+		t.Errorf("ETag(_): want distinct ETags for distinct bodies, got %s twice", a)
+	}
+	if got := ETag([]byte(`{"count":1}`)); got != a {
+		t.Errorf("ETag(_): want %s for the same body, got %s", a, got)
+	}
+	if len(a) != 34 || a[0] != '"' || a[len(a)-1] != '"' {
+		t.Errorf("ETag(_): want a quoted, strong ETag, got %s", a)
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	t.Parallel()
+
+	etag := `"5d41402abc4b2a76b9719d911017c592"`
+	modified := time.Date(2018, time.July, 28, 10, 0, 0, 500, time.UTC)
+
+	tests := []struct {
+		desc         string
+		method       string
+		headers      map[string]string
+		lastModified time.Time
+		want         bool
+	}{
+		{
+			desc:   "is false for unconditional requests",
+			method: http.MethodGet,
+		},
+		{
+			desc:    "is true when If-None-Match lists the ETag",
+			method:  http.MethodGet,
+			headers: map[string]string{"If-None-Match": `"other", W/` + etag},
+			want:    true,
+		},
+		{
+			desc:    "is true when If-None-Match is *",
+			method:  http.MethodHead,
+			headers: map[string]string{"If-None-Match": "*"},
+			want:    true,
+		},
+		{
+			desc:         "is false when If-None-Match doesn't list the ETag, regardless of If-Modified-Since",
+			method:       http.MethodGet,
+			headers:      map[string]string{"If-None-Match": `"other"`, "If-Modified-Since": "Sat, 28 Jul 2018 10:00:00 GMT"},
+			lastModified: modified,
+		},
+		{
+			desc:         "is true when not modified since",
+			method:       http.MethodGet,
+			headers:      map[string]string{"If-Modified-Since": "Sat, 28 Jul 2018 10:00:00 GMT"},
+			lastModified: modified,
+			want:         true,
+		},
+		{
+			desc:         "is false when modified since",
+			method:       http.MethodGet,
+			headers:      map[string]string{"If-Modified-Since": "Sat, 28 Jul 2018 09:59:59 GMT"},
+			lastModified: modified,
+		},
+		{
+			desc:    "is false without a Last-Modified date",
+			method:  http.MethodGet,
+			headers: map[string]string{"If-Modified-Since": "Sat, 28 Jul 2018 10:00:00 GMT"},
+		},
+		{
+			desc:    "is false for unsafe methods",
+			method:  http.MethodPost,
+			headers: map[string]string{"If-None-Match": etag},
+		},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest(test.method, "/api/list", nil)
+		for k, v := range test.headers {
+			r.Header.Set(k, v)
+		}
+		if got := NotModified(r, etag, test.lastModified); got != test.want {
+			t.Errorf("%s: NotModified(_, _, _): want %v, got %v", test.desc, test.want, got)
+		}
+	}
+}
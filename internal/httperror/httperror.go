@@ -3,6 +3,8 @@ package httperror
 
 import (
 	"fmt"
+
+	"github.com/riacataquian/news/api/news"
 )
 
 // HTTPError describes an HTTP error.
@@ -12,6 +14,12 @@ type HTTPError struct {
 	RequestURL  string        `json:"requestUrl,omitempty"`
 	DocsURL     string        `json:"docsUrl,omitempty"`
 	FieldErrors []FieldErrors `json:"errors,omitempty"`
+
+	// Type is the URI of the problem type, from the catalogue, see Problem.
+	// It defaults to the type of Code.
+	Type string `json:"-"`
+	// UpstreamCode is the error code of newsapi, if the error originates from it.
+	UpstreamCode string `json:"-"`
 }
 
 // FieldErrors is a generic error object.
@@ -22,8 +30,8 @@ type FieldErrors struct {
 
 // FieldErr describes an error for a resource or field.
 type FieldErr struct {
-	Field  string
-	Errors []string
+	Field  string   `json:"field"`
+	Errors []string `json:"errors"`
 }
 
 // Error formats and return an HTTPError's message.
@@ -36,3 +44,15 @@ func (e *HTTPError) Error() string {
 
 	return fmt.Sprintf(`%s. See "errors" field for more info.`, e.Message)
 }
+
+// UpstreamCode returns the newsapi error code of err, if any.
+func UpstreamCode(err error) string {
+	switch v := err.(type) {
+	case *HTTPError:
+		return v.UpstreamCode
+	case *news.ErrorResponse:
+		return v.Code
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,126 @@
+package httperror
+
+// This file contains the RFC 7807 problem details rendering of errors.
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// ProblemContentType is the media type of Problem responses.
+const ProblemContentType = "application/problem+json"
+
+// typeBaseURI is the base URI of the problem types, documented in docs/problems.md.
+const typeBaseURI = "https://github.com/riacataquian/news/blob/master/docs/problems.md#"
+
+// The catalogue of problem types.
+//
+// The URIs are stable: clients may rely on them to tell problems apart.
+const (
+	TypeBadRequest       = typeBaseURI + "bad-request"
+	TypeUnauthorized     = typeBaseURI + "unauthorized"
+	TypeForbidden        = typeBaseURI + "forbidden"
+	TypeNotFound         = typeBaseURI + "not-found"
+	TypeMethodNotAllowed = typeBaseURI + "method-not-allowed"
+	TypeRateLimited      = typeBaseURI + "rate-limited"
+	TypeValidation       = typeBaseURI + "validation-error"
+	TypeUpstream         = typeBaseURI + "upstream-error"
+	TypeInternal         = typeBaseURI + "internal-error"
+	TypeNotImplemented   = typeBaseURI + "not-implemented"
+)
+
+// titles are the titles of the problem types.
+var titles = map[string]string{
+	TypeBadRequest:       "Bad request",
+	TypeUnauthorized:     "Unauthorized",
+	TypeForbidden:        "Forbidden",
+	TypeNotFound:         "Resource not found",
+	TypeMethodNotAllowed: "Method not allowed",
+	TypeRateLimited:      "Rate limit exceeded",
+	TypeValidation:       "Invalid parameters",
+	TypeUpstream:         "newsapi request failed",
+	TypeInternal:         "Internal server error",
+	TypeNotImplemented:   "Not implemented",
+}
+
+// types are the default problem types of status codes.
+var types = map[int]string{
+	http.StatusBadRequest:          TypeBadRequest,
+	http.StatusUnauthorized:        TypeUnauthorized,
+	http.StatusForbidden:           TypeForbidden,
+	http.StatusNotFound:            TypeNotFound,
+	http.StatusMethodNotAllowed:    TypeMethodNotAllowed,
+	http.StatusUnprocessableEntity: TypeValidation,
+	http.StatusTooManyRequests:     TypeRateLimited,
+	http.StatusInternalServerError: TypeInternal,
+	http.StatusNotImplemented:      TypeNotImplemented,
+}
+
+// Problem describes an HTTP error as RFC 7807 problem details.
+//
+// See https://tools.ietf.org/html/rfc7807.
+type Problem struct {
+	// Type is the URI identifying the problem type, see the catalogue of Type* constants.
+	Type string `json:"type"`
+	// Title is the summary of the problem type.
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	// Detail explains this occurrence of the problem.
+	Detail string `json:"detail,omitempty"`
+	// Instance is the URI of the request that caused the problem.
+	Instance string `json:"instance,omitempty"`
+
+	// Extension members.
+	DocsURL      string        `json:"docsUrl,omitempty"`
+	UpstreamCode string        `json:"upstreamCode,omitempty"`
+	Errors       []FieldErrors `json:"errors,omitempty"`
+}
+
+// NewProblem returns the problem details of err.
+//
+// Errors other than HTTPError are internal errors, their detail is not disclosed.
+func NewProblem(err error) *Problem {
+	e, ok := err.(*HTTPError)
+	if !ok {
+		return &Problem{
+			Type:   TypeInternal,
+			Title:  titles[TypeInternal],
+			Status: http.StatusInternalServerError,
+		}
+	}
+
+	p := &Problem{
+		Type:         e.Type,
+		Status:       e.Code,
+		Detail:       e.Message,
+		Instance:     e.RequestURL,
+		DocsURL:      e.DocsURL,
+		UpstreamCode: e.UpstreamCode,
+		Errors:       e.FieldErrors,
+	}
+	if p.Type == "" {
+		p.Type = types[e.Code]
+	}
+
+	p.Title = titles[p.Type]
+	if p.Title == "" {
+		p.Title = http.StatusText(e.Code)
+	}
+	// Problems without a specific type are described by their status code.
+	if p.Type == "" {
+		p.Type = "about:blank"
+	}
+	return p
+}
+
+// AcceptsProblem reports whether the request's Accept header opts in to Problem responses.
+func AcceptsProblem(r *http.Request) bool {
+	for _, v := range strings.Split(r.Header.Get("Accept"), ",") {
+		t, _, err := mime.ParseMediaType(strings.TrimSpace(v))
+		if err == nil && t == ProblemContentType {
+			return true
+		}
+	}
+	return false
+}
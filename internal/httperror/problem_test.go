@@ -0,0 +1,125 @@
+package httperror
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+	"github.com/riacataquian/news/api/news"
+)
+
+func TestNewProblem(t *testing.T) {
+	fieldErrs := []FieldErrors{
+		{
+			Message: "invalid params",
+			Errors:  []FieldErr{{Field: "pageSize", Errors: []string{"must be at most 100"}}},
+		},
+	}
+
+	tests := []struct {
+		desc string
+		in   error
+		want *Problem
+	}{
+		{
+			desc: "maps an HTTPError to the type of its status code",
+			in: &HTTPError{
+				Code:        http.StatusBadRequest,
+				Message:     "invalid params",
+				RequestURL:  "/api/list?pageSize=1000",
+				FieldErrors: fieldErrs,
+			},
+			want: &Problem{
+				Type:     TypeBadRequest,
+				Title:    "Bad request",
+				Status:   http.StatusBadRequest,
+				Detail:   "invalid params",
+				Instance: "/api/list?pageSize=1000",
+				Errors:   fieldErrs,
+			},
+		},
+		{
+			desc: "keeps the type and the upstream code of an HTTPError",
+			in: &HTTPError{
+				Type:         TypeUpstream,
+				Code:         http.StatusBadRequest,
+				Message:      "Your API key is invalid.",
+				DocsURL:      "http://fake-docs-url",
+				UpstreamCode: "apiKeyInvalid",
+			},
+			want: &Problem{
+				Type:         TypeUpstream,
+				Title:        "newsapi request failed",
+				Status:       http.StatusBadRequest,
+				Detail:       "Your API key is invalid.",
+				DocsURL:      "http://fake-docs-url",
+				UpstreamCode: "apiKeyInvalid",
+			},
+		},
+		{
+			desc: "describes status codes outside of the catalogue",
+			in:   &HTTPError{Code: http.StatusConflict, Message: "some conflict"},
+			want: &Problem{
+				Type:   "about:blank",
+				Title:  "Conflict",
+				Status: http.StatusConflict,
+				Detail: "some conflict",
+			},
+		},
+		{
+			desc: "hides the detail of internal errors",
+			in:   errors.New("some database error"),
+			want: &Problem{
+				Type:   TypeInternal,
+				Title:  "Internal server error",
+				Status: http.StatusInternalServerError,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got := NewProblem(test.in)
+		if diff := pretty.Compare(got, test.want); diff != "" {
+			t.Errorf("%s: NewProblem(%v) diff: (-got +want)\n%s", test.desc, test.in, diff)
+		}
+	}
+}
+
+func TestAcceptsProblem(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   bool
+	}{
+		{accept: "application/problem+json", want: true},
+		{accept: "application/json, application/problem+json; q=0.9", want: true},
+		{accept: "application/json"},
+		{accept: ""},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/api/list", nil)
+		r.Header.Set("Accept", test.accept)
+		if got := AcceptsProblem(r); got != test.want {
+			t.Errorf("AcceptsProblem(%q): want %v, got %v", test.accept, test.want, got)
+		}
+	}
+}
+
+func TestUpstreamCode(t *testing.T) {
+	tests := []struct {
+		in   error
+		want string
+	}{
+		{in: &news.ErrorResponse{Code: "rateLimited"}, want: "rateLimited"},
+		{in: &HTTPError{UpstreamCode: "apiKeyDisabled"}, want: "apiKeyDisabled"},
+		{in: errors.New("some error")},
+	}
+
+	for _, test := range tests {
+		if got := UpstreamCode(test.in); got != test.want {
+			t.Errorf("UpstreamCode(%v): want %q, got %q", test.in, test.want, got)
+		}
+	}
+}
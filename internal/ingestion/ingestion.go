@@ -0,0 +1,154 @@
+// Package ingestion describes ingestion runs, the records of fetching news
+// from newsapi and persisting them to the data repository.
+package ingestion
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrRunNotFound is the error message if no ingestion run matches the supplied ID.
+	ErrRunNotFound = errors.New("ingestion run not found")
+
+	// ErrCheckpointNotFound is the error message if no checkpoint matches the supplied name.
+	ErrCheckpointNotFound = errors.New("checkpoint not found")
+
+	// ErrBudgetExhausted is the error message if a run stopped because it spent its request budget.
+	ErrBudgetExhausted = errors.New("request budget exhausted")
+)
+
+// Trigger is what started an ingestion run.
+type Trigger string
+
+const (
+	// Scheduled means the run was started by the cron scheduler.
+	Scheduled Trigger = "schedule"
+	// Manual means the run was started on demand, i.e., by an operator.
+	Manual Trigger = "manual"
+	// Backfill means the run fetches historical news, i.e., from the backfill command.
+	Backfill Trigger = "backfill"
+)
+
+// Status is the state of an ingestion run.
+type Status string
+
+const (
+	// Running means the run has started and has not finished yet.
+	Running Status = "running"
+	// Succeeded means every query of the run was fetched and persisted.
+	Succeeded Status = "succeeded"
+	// Failed means the run stopped because of an error.
+	Failed Status = "failed"
+	// Paused means the run spent its request budget before finishing and can be resumed.
+	Paused Status = "paused"
+)
+
+// Store describes a data repository for ingestion runs.
+type Store interface {
+	// CreateRun persists a new run and sets its ID.
+	CreateRun(context.Context, *Run) error
+	// UpdateRun overwrites the status, results and counts of an existing run.
+	UpdateRun(context.Context, *Run) error
+	// ListRuns returns at most `limit` runs, most recent first, skipping `offset` runs,
+	// along with the total count of runs.
+	ListRuns(ctx context.Context, limit, offset int) ([]*Run, int, error)
+	// GetRun returns the run matching the supplied ID or ErrRunNotFound.
+	GetRun(ctx context.Context, id int64) (*Run, error)
+	// LatestRun returns the most recently started run matching the supplied filter or ErrRunNotFound.
+	LatestRun(context.Context, Filter) (*Run, error)
+}
+
+// Filter restricts the runs to look up, its empty fields match any run.
+type Filter struct {
+	Job     string
+	Trigger Trigger
+	Status  Status
+}
+
+// Run describes a single execution of an ingestion job.
+type Run struct {
+	ID      int64   `json:"id"`
+	Job     string  `json:"job"`
+	Trigger Trigger `json:"trigger"`
+	Status  Status  `json:"status"`
+	// StartedAt and FinishedAt are in UTC format.
+	StartedAt  time.Time  `json:"startedAt"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+	// Results are the outcome per query, in order of execution.
+	Results []*Result `json:"results"`
+	// Error summarizes why the run failed, if it did.
+	Error string `json:"error,omitempty"`
+	// ArticlesFetched is the count of articles returned by newsapi.
+	ArticlesFetched int `json:"articlesFetched"`
+	// ArticlesPersisted is the count of articles written to the data repository.
+	ArticlesPersisted int `json:"articlesPersisted"`
+}
+
+// Result describes the outcome of a single query of a run.
+type Result struct {
+	// Key is the request parameter key queried, i.e., domains, sources or query.
+	Key    string   `json:"key"`
+	Values []string `json:"values"`
+	// From and To are the time window queried, if any.
+	From      string `json:"from,omitempty"`
+	To        string `json:"to,omitempty"`
+	Fetched   int    `json:"fetched"`
+	Persisted int    `json:"persisted"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Add appends res to the run's results and accumulates its article counts.
+func (r *Run) Add(res *Result) {
+	r.Results = append(r.Results, res)
+	r.ArticlesFetched += res.Fetched
+	r.ArticlesPersisted += res.Persisted
+}
+
+// Finish marks the run as finished at the supplied time.
+// The run is marked as Failed if err is not nil, Succeeded otherwise,
+// or Paused if err is ErrBudgetExhausted.
+func (r *Run) Finish(at time.Time, err error) {
+	finished := at.UTC()
+	r.FinishedAt = &finished
+
+	if err == ErrBudgetExhausted {
+		r.Status = Paused
+		r.Error = err.Error()
+		return
+	}
+	if err != nil {
+		r.Status = Failed
+		r.Error = err.Error()
+		return
+	}
+	r.Status = Succeeded
+}
+
+// CheckpointStore describes a data repository for checkpoints.
+type CheckpointStore interface {
+	// GetCheckpoint returns the checkpoint matching the supplied name or ErrCheckpointNotFound.
+	GetCheckpoint(ctx context.Context, name string) (*Checkpoint, error)
+	// SaveCheckpoint creates or overwrites the checkpoint matching its name.
+	SaveCheckpoint(context.Context, *Checkpoint) error
+}
+
+// Checkpoint is the progress of a long running ingestion, i.e., a backfill.
+// It points to the next query to perform, so that the ingestion can resume after a crash.
+type Checkpoint struct {
+	// Name identifies the ingestion.
+	Name string `json:"name"`
+	// Day is the start of the day-sized window to query, in UTC format.
+	Day time.Time `json:"day"`
+	// Key and Values are the watchlist entry to query within Day. Entries may share a key, not their values.
+	Key    string   `json:"key"`
+	Values []string `json:"values,omitempty"`
+	// Page is the page to query for the entry.
+	Page int `json:"page"`
+	// Requests is the count of requests spent so far, across runs.
+	Requests int `json:"requests"`
+	// Done is true when there's nothing left to query.
+	Done      bool      `json:"done"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
@@ -0,0 +1,73 @@
+package ingestion
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestAdd(t *testing.T) {
+	run := &Run{}
+	results := []*Result{
+		{Key: "domains", Values: []string{"wsj.com"}, Fetched: 20, Persisted: 20},
+		{Key: "sources", Values: []string{"bloomberg"}, Fetched: 5, Persisted: 3},
+	}
+	for _, res := range results {
+		run.Add(res)
+	}
+
+	want := &Run{
+		Results:           results,
+		ArticlesFetched:   25,
+		ArticlesPersisted: 23,
+	}
+	if diff := pretty.Compare(run, want); diff != "" {
+		desc := "appends results and accumulates article counts"
+		t.Errorf("%s: Add(_): Diff (-got +want)\n%s", desc, diff)
+	}
+}
+
+func TestFinish(t *testing.T) {
+	at := time.Date(2016, time.August, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		desc       string
+		err        error
+		wantStatus Status
+		wantError  string
+	}{
+		{
+			desc:       "marks the run as succeeded",
+			wantStatus: Succeeded,
+		},
+		{
+			desc:       "marks the run as failed with an error summary",
+			err:        errors.New("some error"),
+			wantStatus: Failed,
+			wantError:  "some error",
+		},
+		{
+			desc:       "marks the run as paused when its budget is exhausted",
+			err:        ErrBudgetExhausted,
+			wantStatus: Paused,
+			wantError:  ErrBudgetExhausted.Error(),
+		},
+	}
+
+	for _, test := range tests {
+		run := &Run{Status: Running}
+		run.Finish(at, test.err)
+
+		if run.Status != test.wantStatus {
+			t.Errorf("%s: Finish(_, %v): want status %v, got %v", test.desc, test.err, test.wantStatus, run.Status)
+		}
+		if run.Error != test.wantError {
+			t.Errorf("%s: Finish(_, %v): want error %q, got %q", test.desc, test.err, test.wantError, run.Error)
+		}
+		if run.FinishedAt == nil || !run.FinishedAt.Equal(at) {
+			t.Errorf("%s: Finish(_, %v): want finishedAt %v, got %v", test.desc, test.err, at, run.FinishedAt)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// Package lock abstracts mutual exclusion across server instances,
+// i.e., so that only one replica runs a given cron job at a time.
+package lock
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var (
+	// ErrNotHeld is the error message for releasing a lock that is not held.
+	ErrNotHeld = errors.New("lock is not held")
+)
+
+// Locker describes a lock provider.
+type Locker interface {
+	// TryLock acquires the lock named key without blocking.
+	// It returns false, and a nil Lock, if the lock is held by someone else.
+	TryLock(ctx context.Context, key string) (Lock, bool, error)
+}
+
+// Lock describes an acquired lock.
+type Lock interface {
+	// Unlock releases the lock.
+	Unlock(context.Context) error
+}
+
+// Memory is a Locker that holds locks in memory.
+// It only excludes holders within the same process, use it for tests and single instance deployments.
+type Memory struct {
+	mu   sync.Mutex
+	held map[string]bool
+}
+
+// NewMemory returns a new Memory locker.
+func NewMemory() *Memory {
+	return &Memory{held: make(map[string]bool)}
+}
+
+// TryLock acquires the lock named key if it's not held.
+func (m *Memory) TryLock(_ context.Context, key string) (Lock, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.held[key] {
+		return nil, false, nil
+	}
+	m.held[key] = true
+	return &memoryLock{m: m, key: key}, true, nil
+}
+
+// memoryLock is a lock acquired from a Memory locker.
+type memoryLock struct {
+	m   *Memory
+	key string
+}
+
+// Unlock releases the lock, or returns ErrNotHeld if it was already released.
+func (l *memoryLock) Unlock(_ context.Context) error {
+	l.m.mu.Lock()
+	defer l.m.mu.Unlock()
+
+	if !l.m.held[l.key] {
+		return ErrNotHeld
+	}
+	delete(l.m.held, l.key)
+	return nil
+}
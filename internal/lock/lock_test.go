@@ -0,0 +1,36 @@
+package lock
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMemory(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+
+	l, ok, err := m.TryLock(ctx, "some-key")
+	if !ok || err != nil {
+		t.Fatalf("TryLock(_, %q): want (_, true, nil), got (_, %v, %v)", "some-key", ok, err)
+	}
+
+	if _, ok, err := m.TryLock(ctx, "some-key"); ok || err != nil {
+		t.Errorf("TryLock(_, %q): want (nil, false, nil) when held, got (_, %v, %v)", "some-key", ok, err)
+	}
+
+	if _, ok, err := m.TryLock(ctx, "some-other-key"); !ok || err != nil {
+		t.Errorf("TryLock(_, %q): want (_, true, nil) for another key, got (_, %v, %v)", "some-other-key", ok, err)
+	}
+
+	if err := l.Unlock(ctx); err != nil {
+		t.Fatalf("Unlock(_): want nil, got %v", err)
+	}
+
+	if err := l.Unlock(ctx); err != ErrNotHeld {
+		t.Errorf("Unlock(_): want ErrNotHeld when released, got %v", err)
+	}
+
+	if _, ok, err := m.TryLock(ctx, "some-key"); !ok || err != nil {
+		t.Errorf("TryLock(_, %q): want (_, true, nil) when released, got (_, %v, %v)", "some-key", ok, err)
+	}
+}
@@ -0,0 +1,128 @@
+// Package logging writes structured logs as JSON lines.
+//
+// A request-scoped Logger is carried by the request's context, see NewContext, so that
+// every log of a request, including the ones of its requests to newsapi, carry its request ID.
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Fields are the key-value pairs of a log entry.
+type Fields map[string]interface{}
+
+// Default is the Logger used when a context carries none, writing to stderr.
+var Default = New(os.Stderr)
+
+// Logger writes log entries as JSON lines, each entry carrying the Logger's fields.
+//
+// It's safe for concurrent use.
+type Logger struct {
+	out *output
+	// now is the time entries are logged at, overridable in tests.
+	now func() time.Time
+
+	mu     sync.Mutex
+	fields Fields
+	// upstream is the count of requests made to upstream services, see Upstream.
+	upstream int
+}
+
+// output serializes the writes of the Loggers sharing it.
+type output struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// New returns a Logger writing to w.
+func New(w io.Writer) *Logger {
+	return &Logger{out: &output{w: w}, now: time.Now, fields: Fields{}}
+}
+
+// With returns a new Logger writing to the same output, carrying l's fields plus key set to value.
+// Its count of upstream requests starts at zero.
+func (l *Logger) With(key string, value interface{}) *Logger {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	fields := Fields{key: value}
+	for k, v := range l.fields {
+		if k != key {
+			fields[k] = v
+		}
+	}
+	return &Logger{out: l.out, now: l.now, fields: fields}
+}
+
+// Set sets key to value on the fields of l, i.e., once a request is authenticated.
+func (l *Logger) Set(key string, value interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.fields[key] = value
+}
+
+// Log writes an entry of msg with fields, in addition to the Logger's fields.
+func (l *Logger) Log(msg string, fields Fields) {
+	entry := Fields{}
+	l.mu.Lock()
+	for k, v := range l.fields {
+		entry[k] = v
+	}
+	l.mu.Unlock()
+	for k, v := range fields {
+		entry[k] = v
+	}
+	entry["time"] = l.now().UTC().Format(time.RFC3339Nano)
+	entry["msg"] = msg
+
+	b, err := json.Marshal(entry)
+	if err != nil {
+		b, _ = json.Marshal(Fields{"time": entry["time"], "msg": msg, "error": fmt.Sprintf("error marshalling log fields: %v", err)})
+	}
+
+	l.out.mu.Lock()
+	defer l.out.mu.Unlock()
+	l.out.w.Write(append(b, '\n'))
+}
+
+// Printf writes an entry whose message is formatted as per fmt.Sprintf.
+func (l *Logger) Printf(format string, args ...interface{}) {
+	l.Log(fmt.Sprintf(format, args...), nil)
+}
+
+// Upstream writes an entry of a request to an upstream service, i.e., newsapi, and counts it.
+func (l *Logger) Upstream(fields Fields) {
+	l.mu.Lock()
+	l.upstream++
+	l.mu.Unlock()
+	l.Log("upstream request", fields)
+}
+
+// UpstreamCalls returns the count of requests made to upstream services, see Upstream.
+func (l *Logger) UpstreamCalls() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.upstream
+}
+
+// loggerContextKey is the context key of the request-scoped Logger.
+type loggerContextKey struct{}
+
+// NewContext returns a copy of ctx carrying l.
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, l)
+}
+
+// FromContext returns the Logger carried by ctx, or the Default one if there's none.
+func FromContext(ctx context.Context) *Logger {
+	if l, ok := ctx.Value(loggerContextKey{}).(*Logger); ok {
+		return l
+	}
+	return Default
+}
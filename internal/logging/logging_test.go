@@ -0,0 +1,90 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+// newFakeLogger returns a Logger writing to buf, whose entries are logged at 2016-08-15.
+func newFakeLogger(buf *bytes.Buffer) *Logger {
+	l := New(buf)
+	l.now = func() time.Time {
+		return time.Date(2016, time.August, 15, 0, 0, 0, 0, time.UTC)
+	}
+	return l
+}
+
+// entries decodes the JSON lines of buf.
+func entries(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+
+	var got []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var e map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("error decoding log entry %q: %v", line, err)
+		}
+		got = append(got, e)
+	}
+	return got
+}
+
+func TestLog(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	root := newFakeLogger(&buf)
+	l := root.With("requestId", "some-request-id")
+	l.Set("clientId", 1)
+	l.Log("request", Fields{"status": 200})
+	l.Printf("some %s", "message")
+	root.Printf("without fields")
+
+	want := []map[string]interface{}{
+		{"time": "2016-08-15T00:00:00Z", "msg": "request", "requestId": "some-request-id", "clientId": float64(1), "status": float64(200)},
+		{"time": "2016-08-15T00:00:00Z", "msg": "some message", "requestId": "some-request-id", "clientId": float64(1)},
+		{"time": "2016-08-15T00:00:00Z", "msg": "without fields"},
+	}
+	if diff := pretty.Compare(entries(t, &buf), want); diff != "" {
+		t.Errorf("Log(_, _) diff: (-got +want)\n%s", diff)
+	}
+}
+
+func TestUpstream(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	root := newFakeLogger(&buf)
+	l := root.With("requestId", "some-request-id")
+	l.Upstream(Fields{"url": "https://newsapi.org/v2/everything"})
+	l.Upstream(Fields{"url": "https://newsapi.org/v2/top-headlines"})
+
+	if got := l.UpstreamCalls(); got != 2 {
+		t.Errorf("UpstreamCalls(): want 2, got %d", got)
+	}
+	if got := root.UpstreamCalls(); got != 0 {
+		t.Errorf("UpstreamCalls(): want the parent logger's count to be 0, got %d", got)
+	}
+	if got := entries(t, &buf); len(got) != 2 || got[0]["msg"] != "upstream request" || got[1]["url"] != "https://newsapi.org/v2/top-headlines" {
+		t.Errorf("Upstream(_): want 2 upstream request entries, got %v", got)
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	t.Parallel()
+
+	if got := FromContext(context.Background()); got != Default {
+		t.Errorf("FromContext(_): want the Default logger without a request-scoped one, got %v", got)
+	}
+
+	l := New(&bytes.Buffer{})
+	if got := FromContext(NewContext(context.Background(), l)); got != l {
+		t.Errorf("FromContext(_): want the logger carried by the context, got %v", got)
+	}
+}
@@ -0,0 +1,48 @@
+package logging
+
+// This file contains the request IDs.
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// RequestIDHeader is the header carrying a request's ID, set by clients or proxies to correlate logs.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLen bounds the length of propagated request IDs, so that clients can't flood the logs.
+const maxRequestIDLen = 128
+
+// RequestID returns the ID in r's X-Request-ID header, if valid, otherwise a new random ID.
+//
+// Valid IDs are up to 128 printable ASCII characters.
+func RequestID(r *http.Request) string {
+	if id := r.Header.Get(RequestIDHeader); isValidRequestID(id) {
+		return id
+	}
+	return newRequestID()
+}
+
+// isValidRequestID reports whether id is non-empty, at most maxRequestIDLen long and printable ASCII.
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID returns 16 random bytes, hex encoded.
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand only fails when the OS can't provide randomness, there's no ID to fall back to.
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,49 @@
+package logging
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc      string
+		header    string
+		propagate bool
+	}{
+		{
+			desc:      "propagates a valid request ID",
+			header:    "some-request-id",
+			propagate: true,
+		},
+		{
+			desc: "assigns an ID when missing",
+		},
+		{
+			desc:   "assigns an ID when it contains spaces",
+			header: "some request id",
+		},
+		{
+			desc:   "assigns an ID when too long",
+			header: strings.Repeat("a", maxRequestIDLen+1),
+		},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest("GET", "/api/list", nil)
+		if test.header != "" {
+			r.Header.Set(RequestIDHeader, test.header)
+		}
+
+		got := RequestID(r)
+		if test.propagate && got != test.header {
+			t.Errorf("%s: RequestID(_): want %q, got %q", test.desc, test.header, got)
+		}
+		if !test.propagate && (got == test.header || len(got) != 32) {
+			t.Errorf("%s: RequestID(_): want a new 32 characters ID, got %q", test.desc, got)
+		}
+	}
+}
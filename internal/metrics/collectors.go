@@ -0,0 +1,204 @@
+package metrics
+
+// This file contains the kinds of metrics: counters, histograms and the gauges or counters read from a function.
+
+import (
+	"bufio"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// desc describes a metric: its name, help text and label names.
+type desc struct {
+	metric string
+	help   string
+	labels []string
+}
+
+func (d desc) name() string {
+	return d.metric
+}
+
+// check panics if values don't match the label names of d, a programming error.
+func (d desc) check(values []string) {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", d.metric, len(d.labels), len(values)))
+	}
+}
+
+// CounterVec is a counter per combination of label values.
+type CounterVec struct {
+	desc
+
+	mu     sync.Mutex
+	series map[string]*counter
+}
+
+// counter is a series of a CounterVec.
+type counter struct {
+	values []string
+	v      float64
+}
+
+// CounterVec registers then returns a CounterVec of name, labelled by labels.
+func (r *Registry) CounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{desc: desc{name, help, labels}, series: make(map[string]*counter)}
+	r.register(c)
+	return c
+}
+
+// Inc increments the counter labelled by values.
+func (c *CounterVec) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add adds v, which must not be negative, to the counter labelled by values.
+func (c *CounterVec) Add(v float64, values ...string) {
+	c.check(values)
+	if v < 0 {
+		panic(fmt.Sprintf("metrics: %s can't decrease", c.metric))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	k := labelKey(values)
+	s, ok := c.series[k]
+	if !ok {
+		s = &counter{values: append([]string(nil), values...)}
+		c.series[k] = s
+	}
+	s.v += v
+}
+
+// Value returns the counter labelled by values.
+func (c *CounterVec) Value(values ...string) float64 {
+	c.check(values)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if s, ok := c.series[labelKey(values)]; ok {
+		return s.v
+	}
+	return 0
+}
+
+func (c *CounterVec) write(w *bufio.Writer) {
+	writeHeader(w, c.metric, c.help, "counter")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	keys := make([]string, 0, len(c.series))
+	for k := range c.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		s := c.series[k]
+		writeSample(w, c.metric, c.labels, s.values, s.v)
+	}
+}
+
+// HistogramVec is a histogram per combination of label values.
+type HistogramVec struct {
+	desc
+	// buckets are the sorted upper bounds of the buckets.
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[string]*histogram
+}
+
+// histogram is a series of a HistogramVec.
+type histogram struct {
+	values []string
+	// counts are the count of observations per bucket, not cumulative.
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// HistogramVec registers then returns a HistogramVec of name, counting observations in buckets, labelled by labels.
+func (r *Registry) HistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	b := append([]float64(nil), buckets...)
+	sort.Float64s(b)
+	h := &HistogramVec{desc: desc{name, help, labels}, buckets: b, series: make(map[string]*histogram)}
+	r.register(h)
+	return h
+}
+
+// Observe adds v to the histogram labelled by values.
+func (h *HistogramVec) Observe(v float64, values ...string) {
+	h.check(values)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	k := labelKey(values)
+	s, ok := h.series[k]
+	if !ok {
+		s = &histogram{values: append([]string(nil), values...), counts: make([]uint64, len(h.buckets))}
+		h.series[k] = s
+	}
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.count++
+	s.sum += v
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	writeHeader(w, h.metric, h.help, "histogram")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	keys := make([]string, 0, len(h.series))
+	for k := range h.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	labels := append(append([]string(nil), h.labels...), "le")
+	for _, k := range keys {
+		s := h.series[k]
+		var cumulative uint64
+		for i, upper := range h.buckets {
+			cumulative += s.counts[i]
+			writeSample(w, h.metric+"_bucket", labels, append(append([]string(nil), s.values...), formatFloat(upper)), float64(cumulative))
+		}
+		writeSample(w, h.metric+"_bucket", labels, append(append([]string(nil), s.values...), "+Inf"), float64(s.count))
+		writeSample(w, h.metric+"_sum", h.labels, s.values, s.sum)
+		writeSample(w, h.metric+"_count", h.labels, s.values, float64(s.count))
+	}
+}
+
+// Sample is a sample of a metric read from a function, see Registry.GaugeFunc.
+type Sample struct {
+	// Values are the label values of the sample, in the order of the metric's label names.
+	Values []string
+	Value  float64
+}
+
+// funcCollector is a metric whose samples are read from fn when written, i.e., the stats of a connection pool.
+type funcCollector struct {
+	desc
+	typ string
+	fn  func() []Sample
+}
+
+// GaugeFunc registers a gauge of name, labelled by labels, whose samples are returned by fn when written.
+func (r *Registry) GaugeFunc(name, help string, fn func() []Sample, labels ...string) {
+	r.register(&funcCollector{desc: desc{name, help, labels}, typ: "gauge", fn: fn})
+}
+
+// CounterFunc registers a counter of name, labelled by labels, whose samples are returned by fn when written.
+func (r *Registry) CounterFunc(name, help string, fn func() []Sample, labels ...string) {
+	r.register(&funcCollector{desc: desc{name, help, labels}, typ: "counter", fn: fn})
+}
+
+func (f *funcCollector) write(w *bufio.Writer) {
+	writeHeader(w, f.metric, f.help, f.typ)
+	for _, s := range f.fn() {
+		f.check(s.Values)
+		writeSample(w, f.metric, f.labels, s.Values, s.Value)
+	}
+}
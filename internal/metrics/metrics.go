@@ -0,0 +1,164 @@
+// Package metrics collects the metrics of the server and its jobs,
+// then exposes them in Prometheus' text format, see Registry.Handler.
+//
+// It implements the subset of the format the server needs: counters, gauges and histograms,
+// without depending on a Prometheus client.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the content type of Prometheus' text format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Default is the registry of the metrics below, exposed by the server at /metrics.
+var Default = NewRegistry()
+
+// The metrics of the server and its jobs.
+var (
+	HTTPRequests = Default.CounterVec("news_http_requests_total",
+		"Count of HTTP requests, per route template, method and status code.", "route", "method", "status")
+	HTTPDuration = Default.HistogramVec("news_http_request_duration_seconds",
+		"Latency of HTTP requests, per route template.", DefBuckets, "route")
+
+	UpstreamRequests = Default.CounterVec("news_upstream_requests_total",
+		"Count of requests to newsapi, per endpoint and error code: ok when successful, newsapi's code or error otherwise.", "endpoint", "code")
+	UpstreamDuration = Default.HistogramVec("news_upstream_request_duration_seconds",
+		"Latency of requests to newsapi, per endpoint.", DefBuckets, "endpoint")
+
+	CacheLookups = Default.CounterVec("news_cache_lookups_total",
+		"Count of cache lookups, per cache and result: hit or miss.", "cache", "result")
+
+	CronDuration = Default.HistogramVec("news_cron_job_duration_seconds",
+		"Duration of cron job runs, per job and status.", CronBuckets, "job", "status")
+	CronArticles = Default.CounterVec("news_cron_articles_total",
+		"Count of articles ingested by cron jobs, per job and stage: fetched or persisted.", "job", "stage")
+)
+
+// DefBuckets are the upper bounds, in seconds, of the buckets of request latencies.
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// CronBuckets are the upper bounds, in seconds, of the buckets of cron job durations.
+var CronBuckets = []float64{1, 5, 10, 30, 60, 120, 300, 600, 1800, 3600}
+
+// collector writes the samples of a metric in Prometheus' text format.
+type collector interface {
+	name() string
+	write(w *bufio.Writer)
+}
+
+// Registry holds metrics, written in the order they're registered.
+//
+// It's safe for concurrent use.
+type Registry struct {
+	mu         sync.Mutex
+	collectors []collector
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// register adds c to the registry. Registering a metric name twice is a programming error, it panics.
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, prev := range r.collectors {
+		if prev.name() == c.name() {
+			panic(fmt.Sprintf("metrics: %s registered twice", c.name()))
+		}
+	}
+	r.collectors = append(r.collectors, c)
+}
+
+// WriteTo writes the metrics of r to w in Prometheus' text format.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	collectors := append([]collector(nil), r.collectors...)
+	r.mu.Unlock()
+
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	for _, c := range collectors {
+		c.write(bw)
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+// Handler returns an http.Handler that serves the metrics of r.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("content-type", ContentType)
+		r.WriteTo(w)
+	})
+}
+
+// countingWriter counts the bytes written to w.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(b []byte) (int, error) {
+	n, err := cw.w.Write(b)
+	cw.n += int64(n)
+	return n, err
+}
+
+// writeHeader writes the HELP and TYPE lines of a metric.
+func writeHeader(w *bufio.Writer, name, help, typ string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, typ)
+}
+
+// writeSample writes a sample of name, labelled as per names and values.
+func writeSample(w *bufio.Writer, name string, names, values []string, v float64) {
+	w.WriteString(name)
+	if len(names) > 0 {
+		w.WriteByte('{')
+		for i, n := range names {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, "%s=\"%s\"", n, escape(values[i]))
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(v))
+	w.WriteByte('\n')
+}
+
+// escape escapes a label value as per Prometheus' text format.
+func escape(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
+// formatFloat formats v as per Prometheus' text format, i.e., +Inf.
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+// labelKey joins label values into a map key.
+func labelKey(values []string) string {
+	return strings.Join(values, "\xff")
+}
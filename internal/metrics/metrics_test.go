@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestWriteTo(t *testing.T) {
+	t.Parallel()
+
+	r := NewRegistry()
+	requests := r.CounterVec("test_requests_total", "Count of requests.", "route", "status")
+	duration := r.HistogramVec("test_duration_seconds", "Latency of requests.", []float64{1, 0.1}, "route")
+	r.GaugeFunc("test_connections", "Count of connections.", func() []Sample {
+		return []Sample{{Values: []string{"idle"}, Value: 2}}
+	}, "state")
+
+	requests.Inc("/api/list", "200")
+	requests.Add(2, "/api/list", "200")
+	requests.Inc(`/api/"quoted"`, "500")
+	duration.Observe(0.05, "/api/list")
+	duration.Observe(0.5, "/api/list")
+	duration.Observe(5, "/api/list")
+
+	var buf bytes.Buffer
+	if _, err := r.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo(_): want (_, nil), got (_, %v)", err)
+	}
+
+	want := `# HELP test_requests_total Count of requests.
+# TYPE test_requests_total counter
+test_requests_total{route="/api/\"quoted\"",status="500"} 1
+test_requests_total{route="/api/list",status="200"} 3
+# HELP test_duration_seconds Latency of requests.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{route="/api/list",le="0.1"} 1
+test_duration_seconds_bucket{route="/api/list",le="1"} 2
+test_duration_seconds_bucket{route="/api/list",le="+Inf"} 3
+test_duration_seconds_sum{route="/api/list"} 5.55
+test_duration_seconds_count{route="/api/list"} 3
+# HELP test_connections Count of connections.
+# TYPE test_connections gauge
+test_connections{state="idle"} 2
+`
+	if diff := pretty.Compare(buf.String(), want); diff != "" {
+		t.Errorf("WriteTo(_) diff: (-got +want)\n%s", diff)
+	}
+	if got := requests.Value("/api/list", "200"); got != 3 {
+		t.Errorf("Value(_, _): want 3, got %v", got)
+	}
+}
+
+func TestRegisterTwice(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("CounterVec(_, _): want a panic when registering a name twice")
+		}
+	}()
+
+	r := NewRegistry()
+	r.CounterVec("test_requests_total", "Count of requests.")
+	r.CounterVec("test_requests_total", "Count of requests.")
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	r := NewRegistry()
+	r.CounterVec("test_requests_total", "Count of requests.").Inc()
+
+	w := httptest.NewRecorder()
+	r.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if got := w.Header().Get("content-type"); got != ContentType {
+		t.Errorf("Handler(): want content-type %q, got %q", ContentType, got)
+	}
+	if got, want := w.Body.String(), "# HELP test_requests_total Count of requests.\n# TYPE test_requests_total counter\ntest_requests_total 1\n"; got != want {
+		t.Errorf("Handler(): want body %q, got %q", want, got)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"strconv"
 
 	"github.com/riacataquian/news/internal/newsclient"
+	"github.com/riacataquian/news/internal/validation"
 )
 
 var (
@@ -49,6 +50,31 @@ type Params struct {
 	Page     int    `schema:"page"`
 }
 
+// Validate adds the problems of each invalid parameter to errs.
+//
+// It implements validation.Validator interface.
+func (p *Params) Validate(errs *validation.Errors) {
+	if p.Country == "" && p.Category == "" && p.Sources == "" && p.Query == "" {
+		errs.Add("query", "one of sources, query, country or category is required")
+	}
+
+	if p.Sources != "" {
+		if p.Country != "" {
+			errs.Add("country", "cannot be mixed with sources")
+		}
+		if p.Category != "" {
+			errs.Add("category", "cannot be mixed with sources")
+		}
+	}
+
+	if p.PageSize < 0 || p.PageSize > maxPageSize {
+		errs.Add("pageSize", "must be between 1 and %d", maxPageSize)
+	}
+	if p.Page < 0 {
+		errs.Add("page", "must be positive")
+	}
+}
+
 // Encode encodes a headlines' Params into a query string format. (e.g., foo=bar&wat=lol)
 //
 // It implements Params interface.
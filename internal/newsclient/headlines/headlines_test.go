@@ -2,6 +2,10 @@ package headlines
 
 import (
 	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+	"github.com/riacataquian/news/internal/httperror"
+	"github.com/riacataquian/news/internal/validation"
 )
 
 func TestEncode(t *testing.T) {
@@ -75,3 +79,43 @@ func TestEncodeErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		desc string
+		in   *Params
+		want []httperror.FieldErr
+	}{
+		{
+			desc: "valid params",
+			in:   &Params{Country: "us", Category: "technology", PageSize: 20},
+		},
+		{
+			desc: "no required parameter is supplied",
+			in:   &Params{PageSize: 20},
+			want: []httperror.FieldErr{
+				{Field: "query", Errors: []string{"one of sources, query, country or category is required"}},
+			},
+		},
+		{
+			desc: "sources mixed with country and category, invalid paging",
+			in:   &Params{Sources: "bbc-news", Country: "us", Category: "technology", PageSize: 500, Page: -1},
+			want: []httperror.FieldErr{
+				{Field: "country", Errors: []string{"cannot be mixed with sources"}},
+				{Field: "category", Errors: []string{"cannot be mixed with sources"}},
+				{Field: "pageSize", Errors: []string{"must be between 1 and 100"}},
+				{Field: "page", Errors: []string{"must be positive"}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			errs := &validation.Errors{}
+			test.in.Validate(errs)
+			if diff := pretty.Compare(errs.FieldErrs(), test.want); diff != "" {
+				t.Errorf("%s: Validate(_) diff: (-got +want)\n%s", test.desc, diff)
+			}
+		})
+	}
+}
@@ -5,8 +5,10 @@ import (
 	"errors"
 	"net/url"
 	"strconv"
+	"time"
 
 	"github.com/riacataquian/news/internal/newsclient"
+	"github.com/riacataquian/news/internal/validation"
 )
 
 var (
@@ -64,6 +66,45 @@ type Params struct {
 	Page     int     `schema:"page"`
 }
 
+// Validate adds the problems of each invalid parameter to errs.
+//
+// It implements validation.Validator interface.
+func (p *Params) Validate(errs *validation.Errors) {
+	if p.Query == "" && p.Sources == "" && p.Domains == "" {
+		errs.Add("query", "one of query, sources or domains is required")
+	}
+
+	if p.From != "" && !isISODate(p.From) {
+		errs.Add("from", "must be an ISO date, i.e., 2018-07-28 or 2018-07-28T14:28:41")
+	}
+	if p.To != "" && !isISODate(p.To) {
+		errs.Add("To", "must be an ISO date, i.e., 2018-07-28 or 2018-07-28T14:28:41")
+	}
+
+	switch p.SortBy {
+	case "", Relevancy, Popularity, PublishedAt:
+	default:
+		errs.Add("sortBy", "must be one of %s, %s, %s", Relevancy, Popularity, PublishedAt)
+	}
+
+	if p.PageSize < 0 || p.PageSize > maxPageSize {
+		errs.Add("pageSize", "must be between 1 and %d", maxPageSize)
+	}
+	if p.Page < 0 {
+		errs.Add("page", "must be positive")
+	}
+}
+
+// isISODate reports whether s is a date with an optional time, i.e., 2018-07-28 or 2018-07-28T14:28:41.
+func isISODate(s string) bool {
+	for _, layout := range []string{"2006-01-02", "2006-01-02T15:04:05"} {
+		if _, err := time.Parse(layout, s); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
 // Encode encodes an list's Params into a query string format. (e.g., foo=bar&wat=lol)
 //
 // It implements newsclient.Params interface.
@@ -2,6 +2,10 @@ package list
 
 import (
 	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+	"github.com/riacataquian/news/internal/httperror"
+	"github.com/riacataquian/news/internal/validation"
 )
 
 func TestEncode(t *testing.T) {
@@ -58,3 +62,43 @@ func TestEncodeErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		desc string
+		in   *Params
+		want []httperror.FieldErr
+	}{
+		{
+			desc: "valid params",
+			in:   &Params{Query: "bitcoin", From: "2018-07-28", To: "2018-07-29T14:28:41", SortBy: Popularity, PageSize: 20},
+		},
+		{
+			desc: "no required parameter is supplied",
+			in:   &Params{Page: 2},
+			want: []httperror.FieldErr{
+				{Field: "query", Errors: []string{"one of query, sources or domains is required"}},
+			},
+		},
+		{
+			desc: "invalid dates, sortBy and paging",
+			in:   &Params{Sources: "bbc-news", From: "yesterday", SortBy: "newest", PageSize: 500, Page: -1},
+			want: []httperror.FieldErr{
+				{Field: "from", Errors: []string{"must be an ISO date, i.e., 2018-07-28 or 2018-07-28T14:28:41"}},
+				{Field: "sortBy", Errors: []string{"must be one of relevancy, popularity, publishedAt"}},
+				{Field: "pageSize", Errors: []string{"must be between 1 and 100"}},
+				{Field: "page", Errors: []string{"must be positive"}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			errs := &validation.Errors{}
+			test.in.Validate(errs)
+			if diff := pretty.Compare(errs.FieldErrs(), test.want); diff != "" {
+				t.Errorf("%s: Validate(_) diff: (-got +want)\n%s", test.desc, diff)
+			}
+		})
+	}
+}
@@ -7,9 +7,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/riacataquian/news/api/news"
 	"github.com/riacataquian/news/internal/httperror"
+	"github.com/riacataquian/news/internal/logging"
+	"github.com/riacataquian/news/internal/metrics"
+	"github.com/riacataquian/news/internal/trace"
 )
 
 // Params describes a Client's parameters.
@@ -46,6 +50,9 @@ func New(se ServiceEndpoint) *Client {
 // This can be used to enforce timeouts and cancellations.
 //
 // The request's `X-Api-Key` header is set with the supplied authKey.
+// Responses are requested gzipped to cut the bandwidth to newsapi, http.Transport decompressing them transparently.
+// The request is logged, without its key, by the logger of ctx, see logging.FromContext,
+// and traced as a span of the trace carried by ctx, if any, with the encoded params as attributes.
 func (client *Client) Get(ctx context.Context, authKey string, params Params) (*news.Response, error) {
 	// Encode query parameters from the request origin.
 	q, err := params.Encode()
@@ -53,6 +60,11 @@ func (client *Client) Get(ctx context.Context, authKey string, params Params) (*
 		return nil, err
 	}
 
+	ctx, span := trace.Start(ctx, "newsclient.Get")
+	defer span.End()
+	span.SetAttribute("http.url", client.RequestURL)
+	span.SetAttribute("newsapi.params", q)
+
 	req, err := http.NewRequest(http.MethodGet, client.RequestURL, nil)
 	if err != nil {
 		return nil, err
@@ -63,41 +75,78 @@ func (client *Client) Get(ctx context.Context, authKey string, params Params) (*
 	req.Header.Set("X-Api-Key", authKey)
 	req.URL.RawQuery = q
 
-	// Dispatch HTTP request to newsapi.
+	// Dispatch HTTP request to newsapi, logging it with the request-scoped logger, if any.
+	start := time.Now()
 	resp, err := dispatchReq(req)
+	elapsed := time.Since(start)
+	fields := logging.Fields{
+		"url":       client.RequestURL,
+		"query":     q,
+		"latencyMs": float64(elapsed) / float64(time.Millisecond),
+	}
 	if err != nil {
+		fields["error"] = err.Error()
+	}
+	logging.FromContext(ctx).Upstream(fields)
+	metrics.UpstreamRequests.Inc(client.RequestURL, metricsCode(err))
+	metrics.UpstreamDuration.Observe(elapsed.Seconds(), client.RequestURL)
+	if err != nil {
+		span.RecordError(err)
 		return nil, &httperror.HTTPError{
-			Code:    http.StatusBadRequest,
-			Message: fmt.Sprintf("error while dispatching request: %v", err),
-			DocsURL: client.DocsURL,
+			Code:         http.StatusBadRequest,
+			Message:      fmt.Sprintf("error while dispatching request: %v", err),
+			DocsURL:      client.DocsURL,
+			UpstreamCode: httperror.UpstreamCode(err),
 		}
 	}
 	return resp, nil
 }
 
+// metricsCode returns the code of err counted by metrics.UpstreamRequests:
+// ok when nil, newsapi's error code if any, error otherwise.
+func metricsCode(err error) string {
+	if err == nil {
+		return "ok"
+	}
+	if code := httperror.UpstreamCode(err); code != "" {
+		return code
+	}
+	return "error"
+}
+
 // dispatchReq dispatches the supplied http.Request.
 //
 // It encodes and return a news.ErrorResponse when an error is encountered.
 // Returns news.Response otherwise for successful requests.
-func dispatchReq(r *http.Request) (*news.Response, error) {
+// Reading and decoding the response is traced as its own span, apart from the round trip.
+func dispatchReq(r *http.Request) (res *news.Response, err error) {
 	resp, err := http.DefaultClient.Do(r)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
+	if parent, ok := trace.FromContext(r.Context()); ok {
+		parent.SetAttribute("http.status_code", resp.StatusCode)
+	}
+	_, span := trace.Start(r.Context(), "newsclient.decode")
+	defer func() {
+		span.RecordError(err)
+		span.End()
+	}()
+
 	if resp.StatusCode != http.StatusOK {
-		var res news.ErrorResponse
-		if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		var errRes news.ErrorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&errRes); err != nil {
 			return nil, fmt.Errorf("error decoding response: %v", err)
 		}
-		return nil, &res
+		return nil, &errRes
 	}
 
-	var res news.Response
-	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+	res = new(news.Response)
+	if err := json.NewDecoder(resp.Body).Decode(res); err != nil {
 		return nil, fmt.Errorf("error decoding response: %v", err)
 	}
 
-	return &res, nil
+	return res, nil
 }
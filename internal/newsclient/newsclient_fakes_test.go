@@ -3,9 +3,11 @@ package newsclient
 // This file contains fake definitions of client, response and server.
 
 import (
+	"compress/gzip"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -54,6 +56,8 @@ func setupFakeClient(url string) *Client {
 	}
 }
 
+// setupStubServer returns a server of fakeResponse, or of an error if not isValid.
+// Responses are gzipped if requested, as http.Transport does unless told otherwise.
 func setupStubServer(t *testing.T, isValid bool) *httptest.Server {
 	t.Helper()
 
@@ -68,6 +72,13 @@ func setupStubServer(t *testing.T, isValid bool) *httptest.Server {
 		if err != nil {
 			t.Fatalf("error marshalling response: %v", err)
 		}
-		w.Write(b)
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			w.Write(b)
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		zw := gzip.NewWriter(w)
+		zw.Write(b)
+		zw.Close()
 	}))
 }
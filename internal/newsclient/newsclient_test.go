@@ -1,9 +1,13 @@
 package newsclient
 
 import (
+	"compress/gzip"
 	"context"
+	"encoding/json"
 	"errors"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/kylelemons/godebug/pretty"
@@ -57,6 +61,34 @@ func TestGet(t *testing.T) {
 	}
 }
 
+func TestGetGzipped(t *testing.T) {
+	var acceptEncoding string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		acceptEncoding = r.Header.Get("Accept-Encoding")
+		w.Header().Set("Content-Encoding", "gzip")
+		zw := gzip.NewWriter(w)
+		json.NewEncoder(zw).Encode(fakeResponse)
+		zw.Close()
+	}))
+	defer server.Close()
+
+	client := setupFakeClient(server.URL)
+	testAuthKey := "test-auth-key"
+	params := fakeParams{lang: "en"}
+
+	desc := "requests gzipped responses and decompresses them"
+	got, err := client.Get(context.Background(), testAuthKey, params)
+	if err != nil {
+		t.Fatalf("%s: Get(%s, %v): want (%v, nil), got (%v, %v)", desc, testAuthKey, params, fakeResponse, got, err)
+	}
+	if !strings.Contains(acceptEncoding, "gzip") {
+		t.Errorf("%s: Get(%s, %v): want Accept-Encoding gzip, got %q", desc, testAuthKey, params, acceptEncoding)
+	}
+	if diff := pretty.Compare(got, fakeResponse); diff != "" {
+		t.Errorf("%s: Get(%s, %v) diff: (-got +want)\n%s", desc, testAuthKey, params, diff)
+	}
+}
+
 func TestGetErrors(t *testing.T) {
 	tests := []struct {
 		desc          string
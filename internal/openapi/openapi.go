@@ -0,0 +1,280 @@
+// Package openapi generates OpenAPI 3 documents out of Go types, see https://spec.openapis.org/oas/v3.0.3.
+//
+// Schemas are derived by reflection as per the types' `json` struct tags, and parameters as per their `schema`
+// struct tags, the ones gorilla/schema decodes requests with. Exported struct types are registered as components.
+package openapi
+
+import (
+	"go/ast"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Version is the version of the OpenAPI specification of the documents.
+const Version = "3.0.3"
+
+// Document is an OpenAPI document.
+type Document struct {
+	OpenAPI    string                `json:"openapi"`
+	Info       Info                  `json:"info"`
+	Servers    []Server              `json:"servers,omitempty"`
+	Paths      map[string]PathItem   `json:"paths"`
+	Components Components            `json:"components"`
+	Security   []SecurityRequirement `json:"security,omitempty"`
+}
+
+// Info describes the API.
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Server is a URL the API is served at, relative to the document's.
+type Server struct {
+	URL string `json:"url"`
+}
+
+// PathItem are the operations of a path, by lowercase HTTP method.
+type PathItem map[string]*Operation
+
+// Operation is an HTTP method of a path.
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Parameters  []Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+// Parameter is a path or query parameter of an operation.
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+// RequestBody is the body of an operation's request.
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response is a response of an operation.
+type Response struct {
+	Description string                `json:"description"`
+	Headers     map[string]HeaderSpec `json:"headers,omitempty"`
+	Content     map[string]MediaType  `json:"content,omitempty"`
+}
+
+// HeaderSpec is a header of a response.
+type HeaderSpec struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// MediaType is the schema of a content type.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components are the schemas and the security schemes referenced by a document.
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas,omitempty"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme is a way requests are authenticated.
+type SecurityScheme struct {
+	Type        string `json:"type"`
+	Scheme      string `json:"scheme,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// SecurityRequirement are the security schemes, by name, required by the operations, along with their scopes.
+type SecurityRequirement map[string][]string
+
+// Schema is the JSON schema of a value, or a reference to a component's.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Generator generates the schemas of Go types, registering the exported struct types as components.
+type Generator struct {
+	schemas map[string]*Schema
+	// names are the component names of the registered types.
+	names map[reflect.Type]string
+}
+
+// NewGenerator returns a Generator without components.
+func NewGenerator() *Generator {
+	return &Generator{
+		schemas: make(map[string]*Schema),
+		names:   make(map[reflect.Type]string),
+	}
+}
+
+// Schemas returns the schemas of the components registered so far, by name.
+func (g *Generator) Schemas() map[string]*Schema {
+	return g.schemas
+}
+
+// Schema returns the schema of v's type, a reference for exported struct types.
+func (g *Generator) Schema(v interface{}) *Schema {
+	if v == nil {
+		return &Schema{}
+	}
+	return g.schemaOf(reflect.TypeOf(v))
+}
+
+// Ref returns the reference to the component named name.
+func Ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// Parameters returns the parameters of the struct v, one per field with a `schema` tag, located in in:
+// query or path, path parameters being required.
+func (g *Generator) Parameters(in string, v interface{}) []Parameter {
+	if v == nil {
+		return nil
+	}
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var params []Parameter
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("schema"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		params = append(params, Parameter{
+			Name:     name,
+			In:       in,
+			Required: in == "path",
+			Schema:   g.schemaOf(f.Type),
+		})
+	}
+	return params
+}
+
+func (g *Generator) schemaOf(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Struct && ast.IsExported(t.Name()):
+		return Ref(g.register(t))
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		return g.object(t)
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: g.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaOf(t.Elem())}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	default:
+		// Interfaces, i.e., SuccessResponse.Data, hold any value.
+		return &Schema{}
+	}
+}
+
+// register registers the exported struct type t as a component, once, and returns its name.
+// Types of distinct packages sharing a name are prefixed with their package's name.
+func (g *Generator) register(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+
+	name := t.Name()
+	if _, taken := g.schemas[name]; taken {
+		pkg := path.Base(t.PkgPath())
+		name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+	}
+	g.names[t] = name
+	// The placeholder ends the recursion of self-referencing types.
+	g.schemas[name] = &Schema{}
+	*g.schemas[name] = *g.object(t)
+	return name
+}
+
+// object returns the schema of the struct t, as encoding/json marshals it.
+func (g *Generator) object(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+
+		tag := strings.Split(f.Tag.Get("json"), ",")
+		if tag[0] == "-" {
+			continue
+		}
+		name := tag[0]
+		if name == "" && f.Anonymous {
+			// Untagged embedded structs have their fields promoted.
+			embedded := f.Type
+			for embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			inner := g.object(embedded)
+			for k, v := range inner.Properties {
+				s.Properties[k] = v
+			}
+			s.Required = append(s.Required, inner.Required...)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		s.Properties[name] = g.schemaOf(f.Type)
+		if !hasOption(tag[1:], "omitempty") {
+			s.Required = append(s.Required, name)
+		}
+	}
+	sort.Strings(s.Required)
+	return s
+}
+
+// hasOption reports whether the options of a struct tag include opt.
+func hasOption(options []string, opt string) bool {
+	for _, o := range options {
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
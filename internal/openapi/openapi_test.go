@@ -0,0 +1,81 @@
+package openapi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+type testSource struct {
+	ID string `json:"id"`
+}
+
+type Article struct {
+	*testSource `json:"source"`
+	Title       string           `json:"title"`
+	PublishedAt time.Time        `json:"publishedAt"`
+	Tags        []string         `json:"tags,omitempty"`
+	Scores      map[string]int64 `json:"scores,omitempty"`
+	Related     []*Article       `json:"related,omitempty"`
+	Extra       interface{}      `json:"extra"`
+	Internal    string           `json:"-"`
+	private     string
+}
+
+type testParams struct {
+	Query    string `schema:"query"`
+	PageSize int    `schema:"pageSize"`
+	Ignored  string
+}
+
+func TestSchema(t *testing.T) {
+	t.Parallel()
+
+	g := NewGenerator()
+	if diff := pretty.Compare(g.Schema([]*Article{}), &Schema{Type: "array", Items: Ref("Article")}); diff != "" {
+		t.Errorf("Schema(_) diff: (-got +want)\n%s", diff)
+	}
+
+	want := map[string]*Schema{
+		"Article": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"source": {
+					Type:       "object",
+					Properties: map[string]*Schema{"id": {Type: "string"}},
+					Required:   []string{"id"},
+				},
+				"title":       {Type: "string"},
+				"publishedAt": {Type: "string", Format: "date-time"},
+				"tags":        {Type: "array", Items: &Schema{Type: "string"}},
+				"scores":      {Type: "object", AdditionalProperties: &Schema{Type: "integer", Format: "int64"}},
+				"related":     {Type: "array", Items: Ref("Article")},
+				"extra":       {},
+			},
+			Required: []string{"extra", "publishedAt", "source", "title"},
+		},
+	}
+	if diff := pretty.Compare(g.Schemas(), want); diff != "" {
+		t.Errorf("Schemas() diff: (-got +want)\n%s", diff)
+	}
+}
+
+func TestParameters(t *testing.T) {
+	t.Parallel()
+
+	want := []Parameter{
+		{Name: "query", In: "query", Schema: &Schema{Type: "string"}},
+		{Name: "pageSize", In: "query", Schema: &Schema{Type: "integer"}},
+	}
+	if diff := pretty.Compare(NewGenerator().Parameters("query", &testParams{}), want); diff != "" {
+		t.Errorf("Parameters(_, _) diff: (-got +want)\n%s", diff)
+	}
+
+	path := NewGenerator().Parameters("path", struct {
+		ID int64 `schema:"id"`
+	}{})
+	if len(path) != 1 || !path[0].Required || path[0].In != "path" {
+		t.Errorf("Parameters(_, _): want a required path parameter, got %+v", path)
+	}
+}
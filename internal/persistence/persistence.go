@@ -3,6 +3,8 @@
 package persistence
 
 import (
+	"context"
+
 	"github.com/riacataquian/news/api/news"
 	"github.com/riacataquian/news/internal/clock"
 	"github.com/riacataquian/news/internal/store"
@@ -33,9 +35,9 @@ func ScanRow(row *news.News) *News {
 	}
 }
 
-// Create persists rows to the supplied data repository.
+// Create persists rows to the supplied data repository, within ctx.
 // It makes use of timer to retrieve time.Now().Nanosecond() which is used as a resource ID.
-func (row *News) Create(repo store.Store, timer clock.Time) error {
+func (row *News) Create(ctx context.Context, repo store.Store, timer clock.Time) error {
 	nsecid := timer.Now().Nanosecond()
 
 	var srow store.Row
@@ -54,14 +56,14 @@ func (row *News) Create(repo store.Store, timer clock.Time) error {
 
 	nc := []string{"app_id", "author", "title", "description", "url", "image_url", "published_at"}
 	rows := []store.Row{nrow}
-	if err := repo.Create("news", nc, rows...); err != nil {
+	if err := repo.Create(ctx, "news", nc, rows...); err != nil {
 		return err
 	}
 
 	if row.Source != nil {
 		sc := []string{"news_id", "id", "name"}
 		rows := []store.Row{srow}
-		if err := repo.Create("source", sc, rows...); err != nil {
+		if err := repo.Create(ctx, "source", sc, rows...); err != nil {
 			return err
 		}
 	}
@@ -3,9 +3,11 @@ package persistence
 // This file contains fake implementations used for testing.
 
 import (
+	"context"
 	"errors"
 	"time"
 
+	"github.com/riacataquian/news/internal/clock"
 	"github.com/riacataquian/news/internal/store"
 )
 
@@ -15,7 +17,7 @@ type fakestore struct {
 	rows []store.Row
 }
 
-func (f *fakestore) Create(table string, cols []string, rows ...store.Row) error {
+func (f *fakestore) Create(_ context.Context, table string, cols []string, rows ...store.Row) error {
 	if f.isValid {
 		f.rows = append(f.rows, rows...)
 		return nil
@@ -24,15 +26,17 @@ func (f *fakestore) Create(table string, cols []string, rows ...store.Row) error
 	return errors.New("some store error")
 }
 
+// fakeclock is a fake clock set to 2016-08-15 plus nsec nanoseconds,
+// whose elapsed time is always 123.
 type fakeclock struct {
-	nsec int
+	*clock.Fake
 }
 
-func (c fakeclock) Now() time.Time {
-	return time.Date(2016, time.August, 15, 0, 0, 0, c.nsec, time.UTC)
+func newFakeclock(nsec int) *fakeclock {
+	return &fakeclock{Fake: clock.NewFake(time.Date(2016, time.August, 15, 0, 0, 0, nsec, time.UTC))}
 }
 
-func (c fakeclock) Since(_ time.Time) time.Duration {
+func (c *fakeclock) Since(_ time.Time) time.Duration {
 	return 123
 }
 
@@ -1,6 +1,7 @@
 package persistence
 
 import (
+	"context"
 	"os"
 	"testing"
 	"time"
@@ -31,7 +32,7 @@ func TestPersist(t *testing.T) {
 	}{
 		{
 			desc:  "persists news rows to repo",
-			clock: &fakeclock{nsec: 123},
+			clock: newFakeclock(123),
 			repo:  &fakestore{isValid: true},
 			want: []store.Row{
 				toStoreRow(
@@ -57,7 +58,7 @@ func TestPersist(t *testing.T) {
 		},
 		{
 			desc:  "persists news rows and its sources to repo",
-			clock: &fakeclock{nsec: 123},
+			clock: newFakeclock(123),
 			repo:  &fakestore{isValid: true},
 			want: []store.Row{
 				toStoreRow(
@@ -93,20 +94,20 @@ func TestPersist(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		err := test.in.Create(test.repo, test.clock)
+		err := test.in.Create(context.Background(), test.repo, test.clock)
 		if err != nil {
-			t.Errorf("Create(_, %v): want nil, got %v", test.in, err)
+			t.Errorf("Create(_, _, %v): want nil, got %v", test.in, err)
 		}
 
 		if diff := pretty.Compare(test.repo.rows, test.want); diff != "" {
-			t.Errorf("%s: Create(_, %v): Diff (-got +want)\n%s", test.desc, test.in, diff)
+			t.Errorf("%s: Create(_, _, %v): Diff (-got +want)\n%s", test.desc, test.in, diff)
 		}
 	}
 }
 
 func TestCreateError(t *testing.T) {
 	repo := &fakestore{isValid: false}
-	clock := fakeclock{}
+	clock := newFakeclock(0)
 	in := &News{
 		News: &news.News{
 			Source: &news.Source{
@@ -122,7 +123,7 @@ func TestCreateError(t *testing.T) {
 		},
 	}
 
-	err := in.Create(repo, clock)
+	err := in.Create(context.Background(), repo, clock)
 	if err == nil {
 		desc := "returns an error when repo errored"
 		t.Errorf("%s: Create(_, %v, %v, %v) = (_, nil), want (_, error)", desc, repo, clock, in)
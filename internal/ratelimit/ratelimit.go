@@ -0,0 +1,243 @@
+// Package ratelimit caps how many requests each client can make per minute and per day,
+// so that a single client can't exhaust the shared newsapi quota.
+//
+// Requests are counted in fixed windows, i.e., from the start of the minute and from midnight UTC.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/riacataquian/news/internal/clock"
+)
+
+// DefaultTier is the tier of clients without one, or with an unknown one.
+const DefaultTier = "default"
+
+// pruneInterval is how often expired counters are discarded.
+const pruneInterval = time.Minute
+
+// ErrInvalidTiers is the error message for malformed tiers.
+var ErrInvalidTiers = errors.New("invalid rate limit tiers")
+
+// DefaultTiers are the tiers used when none are configured.
+var DefaultTiers = map[string]Tier{
+	DefaultTier: {PerMinute: 60, PerDay: 10000},
+}
+
+// Tier describes the request limits of a class of clients.
+// A zero limit means no limit.
+type Tier struct {
+	PerMinute int
+	PerDay    int
+}
+
+// Store describes a repository of request counters.
+type Store interface {
+	// Incr increments the counter of bucket for the window starting at start, then returns its count.
+	// The counter can be discarded once expired.
+	Incr(ctx context.Context, bucket string, start, expires time.Time) (int, error)
+	// Prune discards the counters expired by now.
+	Prune(ctx context.Context, now time.Time) error
+}
+
+// Result is the outcome of counting a request.
+type Result struct {
+	// Allowed is false when the request exceeds a limit.
+	Allowed bool
+	// Limit, Remaining and Reset describe the most constraining window:
+	// its limit, the requests left in it and when it ends.
+	Limit     int
+	Remaining int
+	Reset     time.Time
+	// RetryAfter is how long to wait before retrying a request that is not allowed.
+	RetryAfter time.Duration
+}
+
+// Limiter counts requests per client against their tier's limits.
+type Limiter struct {
+	store Store
+	tiers map[string]Tier
+	clock clock.Time
+
+	mu         sync.Mutex
+	lastPruned time.Time
+}
+
+// New returns a Limiter counting requests in store as per tiers.
+func New(store Store, tiers map[string]Tier) *Limiter {
+	return &Limiter{store: store, tiers: tiers, clock: clock.New()}
+}
+
+// window is a fixed window of time in which requests are counted.
+type window struct {
+	name  string
+	limit int
+	start time.Time
+	end   time.Time
+}
+
+// Allow counts a request of client as per the limits of the named tier.
+func (l *Limiter) Allow(ctx context.Context, client, tier string) (*Result, error) {
+	t, ok := l.tiers[tier]
+	if !ok {
+		t = l.tiers[DefaultTier]
+	}
+
+	now := l.clock.Now().UTC()
+	l.prune(ctx, now)
+
+	minute := now.Truncate(time.Minute)
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	windows := []window{
+		{name: "minute", limit: t.PerMinute, start: minute, end: minute.Add(time.Minute)},
+		{name: "day", limit: t.PerDay, start: day, end: day.AddDate(0, 0, 1)},
+	}
+
+	var res *Result
+	for _, w := range windows {
+		if w.limit <= 0 {
+			continue
+		}
+
+		count, err := l.store.Incr(ctx, client+":"+w.name, w.start, w.end)
+		if err != nil {
+			return nil, fmt.Errorf("counting request of %s: %v", client, err)
+		}
+
+		remaining := w.limit - count
+		if remaining < 0 {
+			remaining = 0
+		}
+		cur := &Result{
+			Allowed:   count <= w.limit,
+			Limit:     w.limit,
+			Remaining: remaining,
+			Reset:     w.end,
+		}
+		if !cur.Allowed {
+			cur.RetryAfter = w.end.Sub(now)
+		}
+
+		// Report the window denying the request, or else the one with the fewest requests left.
+		switch {
+		case res == nil:
+			res = cur
+		case res.Allowed && !cur.Allowed:
+			res = cur
+		case res.Allowed == cur.Allowed && cur.Remaining < res.Remaining:
+			res = cur
+		}
+	}
+
+	if res == nil {
+		return &Result{Allowed: true}, nil
+	}
+	return res, nil
+}
+
+// prune discards the expired counters at most once per pruneInterval.
+func (l *Limiter) prune(ctx context.Context, now time.Time) {
+	l.mu.Lock()
+	if now.Sub(l.lastPruned) < pruneInterval {
+		l.mu.Unlock()
+		return
+	}
+	l.lastPruned = now
+	l.mu.Unlock()
+
+	// Expired counters don't change the count of new windows, a failed prune is retried later.
+	l.store.Prune(ctx, now)
+}
+
+// ParseTiers parses tiers formatted as `name=perMinute/perDay` separated by semicolons,
+// i.e., "default=60/10000;partner=600/100000".
+// A DefaultTier is added from DefaultTiers if missing.
+func ParseTiers(s string) (map[string]Tier, error) {
+	tiers := make(map[string]Tier)
+	for _, v := range strings.Split(s, ";") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+
+		parts := strings.SplitN(v, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("%w: %q, expects name=perMinute/perDay", ErrInvalidTiers, v)
+		}
+		limits := strings.SplitN(parts[1], "/", 2)
+		if len(limits) != 2 {
+			return nil, fmt.Errorf("%w: %q, expects name=perMinute/perDay", ErrInvalidTiers, v)
+		}
+		perMinute, err := strconv.Atoi(limits[0])
+		if err != nil || perMinute < 0 {
+			return nil, fmt.Errorf("%w: %q, invalid per minute limit", ErrInvalidTiers, v)
+		}
+		perDay, err := strconv.Atoi(limits[1])
+		if err != nil || perDay < 0 {
+			return nil, fmt.Errorf("%w: %q, invalid per day limit", ErrInvalidTiers, v)
+		}
+
+		tiers[strings.TrimSpace(parts[0])] = Tier{PerMinute: perMinute, PerDay: perDay}
+	}
+
+	if _, ok := tiers[DefaultTier]; !ok {
+		tiers[DefaultTier] = DefaultTiers[DefaultTier]
+	}
+	return tiers, nil
+}
+
+// Memory is a Store that keeps counters in memory.
+// Counts are not shared across processes, use it for tests and single instance deployments.
+type Memory struct {
+	mu       sync.Mutex
+	counters map[counterKey]*counter
+}
+
+type counterKey struct {
+	bucket string
+	start  time.Time
+}
+
+type counter struct {
+	count   int
+	expires time.Time
+}
+
+// NewMemory returns a new Memory store.
+func NewMemory() *Memory {
+	return &Memory{counters: make(map[counterKey]*counter)}
+}
+
+// Incr increments the counter of bucket for the window starting at start.
+func (m *Memory) Incr(_ context.Context, bucket string, start, expires time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	k := counterKey{bucket: bucket, start: start}
+	c, ok := m.counters[k]
+	if !ok {
+		c = &counter{expires: expires}
+		m.counters[k] = c
+	}
+	c.count++
+	return c.count, nil
+}
+
+// Prune discards the counters expired by now.
+func (m *Memory) Prune(_ context.Context, now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for k, c := range m.counters {
+		if !now.Before(c.expires) {
+			delete(m.counters, k)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+	"github.com/riacataquian/news/internal/clock"
+)
+
+func TestAllow(t *testing.T) {
+	ctx := context.Background()
+	fake := clock.NewFake(time.Date(2018, time.July, 28, 23, 59, 30, 0, time.UTC))
+	l := New(NewMemory(), map[string]Tier{
+		DefaultTier: {PerMinute: 2, PerDay: 3},
+		"unlimited": {},
+	})
+	l.clock = fake
+
+	minuteEnd := time.Date(2018, time.July, 29, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		desc    string
+		advance time.Duration
+		client  string
+		tier    string
+		want    *Result
+	}{
+		{
+			desc:   "allows the first request",
+			client: "some-client",
+			want:   &Result{Allowed: true, Limit: 2, Remaining: 1, Reset: minuteEnd},
+		},
+		{
+			desc:   "allows requests up to the per minute limit",
+			client: "some-client",
+			want:   &Result{Allowed: true, Limit: 2, Remaining: 0, Reset: minuteEnd},
+		},
+		{
+			desc:   "denies requests over the per minute limit",
+			client: "some-client",
+			want:   &Result{Limit: 2, Remaining: 0, Reset: minuteEnd, RetryAfter: 30 * time.Second},
+		},
+		{
+			desc:   "counts requests per client",
+			client: "some-other-client",
+			tier:   "unknown",
+			want:   &Result{Allowed: true, Limit: 2, Remaining: 1, Reset: minuteEnd},
+		},
+		{
+			desc:   "allows every request of an unlimited tier",
+			client: "some-unlimited-client",
+			tier:   "unlimited",
+			want:   &Result{Allowed: true},
+		},
+		{
+			desc:    "resets the counts in new windows",
+			advance: 30 * time.Second,
+			client:  "some-client",
+			want:    &Result{Allowed: true, Limit: 2, Remaining: 1, Reset: minuteEnd.Add(time.Minute)},
+		},
+	}
+
+	for _, test := range tests {
+		fake.Advance(test.advance)
+
+		got, err := l.Allow(ctx, test.client, test.tier)
+		if err != nil {
+			t.Fatalf("%s: Allow(_, %q, %q): want (_, nil), got (_, %v)", test.desc, test.client, test.tier, err)
+		}
+		if diff := pretty.Compare(got, test.want); diff != "" {
+			t.Errorf("%s: Allow(_, %q, %q) diff: (-got +want)\n%s", test.desc, test.client, test.tier, diff)
+		}
+	}
+}
+
+func TestAllowPerDay(t *testing.T) {
+	ctx := context.Background()
+	fake := clock.NewFake(time.Date(2018, time.July, 28, 12, 0, 0, 0, time.UTC))
+	l := New(NewMemory(), map[string]Tier{DefaultTier: {PerMinute: 2, PerDay: 3}})
+	l.clock = fake
+
+	for i := 0; i < 3; i++ {
+		if got, err := l.Allow(ctx, "some-client", DefaultTier); err != nil || !got.Allowed {
+			t.Fatalf("Allow(_, _, _): want request %d to be allowed, got (%+v, %v)", i+1, got, err)
+		}
+		fake.Advance(time.Minute)
+	}
+
+	dayEnd := time.Date(2018, time.July, 29, 0, 0, 0, 0, time.UTC)
+	want := &Result{Limit: 3, Remaining: 0, Reset: dayEnd, RetryAfter: dayEnd.Sub(fake.Now())}
+	got, err := l.Allow(ctx, "some-client", DefaultTier)
+	if err != nil {
+		t.Fatalf("Allow(_, _, _): want (_, nil), got (_, %v)", err)
+	}
+	if diff := pretty.Compare(got, want); diff != "" {
+		t.Errorf("Allow(_, _, _) diff: (-got +want)\n%s", diff)
+	}
+}
+
+func TestMemoryPrune(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+	start := time.Date(2018, time.July, 28, 0, 0, 0, 0, time.UTC)
+	m.Incr(ctx, "some-client:minute", start, start.Add(time.Minute))
+	m.Incr(ctx, "some-client:day", start, start.AddDate(0, 0, 1))
+
+	m.Prune(ctx, start.Add(time.Minute))
+
+	if len(m.counters) != 1 {
+		t.Fatalf("Prune(_, _): want 1 counter left, got %d", len(m.counters))
+	}
+	if got, _ := m.Incr(ctx, "some-client:day", start, start.AddDate(0, 0, 1)); got != 2 {
+		t.Errorf("Prune(_, _): want the unexpired counter to be kept, got count %d", got)
+	}
+}
+
+func TestParseTiers(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    map[string]Tier
+		wantErr error
+	}{
+		{
+			in: "default=10/100;partner=600/100000",
+			want: map[string]Tier{
+				DefaultTier: {PerMinute: 10, PerDay: 100},
+				"partner":   {PerMinute: 600, PerDay: 100000},
+			},
+		},
+		{
+			in: "partner=600/0",
+			want: map[string]Tier{
+				DefaultTier: DefaultTiers[DefaultTier],
+				"partner":   {PerMinute: 600},
+			},
+		},
+		{in: "partner=600", wantErr: ErrInvalidTiers},
+		{in: "partner=a/100", wantErr: ErrInvalidTiers},
+		{in: "=1/100", wantErr: ErrInvalidTiers},
+	}
+
+	for _, test := range tests {
+		got, err := ParseTiers(test.in)
+		if !errors.Is(err, test.wantErr) {
+			t.Errorf("ParseTiers(%q): want (_, %v), got (_, %v)", test.in, test.wantErr, err)
+		}
+		if diff := pretty.Compare(got, test.want); diff != "" {
+			t.Errorf("ParseTiers(%q) diff: (-got +want)\n%s", test.in, diff)
+		}
+	}
+}
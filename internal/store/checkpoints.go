@@ -0,0 +1,67 @@
+package store
+
+// This file contains the Postgresql implementation of ingestion.CheckpointStore.
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/riacataquian/news/internal/ingestion"
+)
+
+// Repo records checkpoints.
+var _ ingestion.CheckpointStore = (*Repo)(nil)
+
+// checkpointRow is a backfill_checkpoints entry.
+type checkpointRow struct {
+	Name      string         `db:"name"`
+	Day       time.Time      `db:"day"`
+	Key       string         `db:"key"`
+	Values    pq.StringArray `db:"key_values"`
+	Page      int            `db:"page"`
+	Requests  int            `db:"requests"`
+	Done      bool           `db:"done"`
+	UpdatedAt time.Time      `db:"updated_at"`
+}
+
+// GetCheckpoint returns the checkpoint matching name, or ingestion.ErrCheckpointNotFound if none.
+func (repo *Repo) GetCheckpoint(ctx context.Context, name string) (*ingestion.Checkpoint, error) {
+	var row checkpointRow
+	q := "SELECT name, day, key, key_values, page, requests, done, updated_at FROM backfill_checkpoints WHERE name = $1"
+	err := repo.GetContext(ctx, &row, q, name)
+	if err == sql.ErrNoRows {
+		return nil, ingestion.ErrCheckpointNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting checkpoint %q: %v", name, err)
+	}
+
+	return &ingestion.Checkpoint{
+		Name:      row.Name,
+		Day:       row.Day.UTC(),
+		Key:       row.Key,
+		Values:    []string(row.Values),
+		Page:      row.Page,
+		Requests:  row.Requests,
+		Done:      row.Done,
+		UpdatedAt: row.UpdatedAt.UTC(),
+	}, nil
+}
+
+// SaveCheckpoint inserts cp to the backfill_checkpoints table, or overwrites the one matching its name.
+func (repo *Repo) SaveCheckpoint(ctx context.Context, cp *ingestion.Checkpoint) error {
+	q := `INSERT INTO backfill_checkpoints (name, day, key, key_values, page, requests, done, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (name) DO UPDATE
+		SET day = $2, key = $3, key_values = $4, page = $5, requests = $6, done = $7, updated_at = $8`
+	values := make(pq.StringArray, len(cp.Values))
+	copy(values, cp.Values)
+	_, err := repo.ExecContext(ctx, q, cp.Name, cp.Day, cp.Key, values, cp.Page, cp.Requests, cp.Done, cp.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("saving checkpoint %q: %v", cp.Name, err)
+	}
+	return nil
+}
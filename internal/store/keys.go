@@ -0,0 +1,112 @@
+package store
+
+// This file contains the Postgresql implementation of auth.KeyStore.
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/riacataquian/news/internal/auth"
+)
+
+// Repo stores client keys.
+var _ auth.KeyStore = (*Repo)(nil)
+
+// keyRow is a client_keys entry.
+type keyRow struct {
+	ID        int64          `db:"id"`
+	Name      string         `db:"name"`
+	Prefix    string         `db:"prefix"`
+	Hash      string         `db:"hash"`
+	Scopes    pq.StringArray `db:"scopes"`
+	Tier      string         `db:"tier"`
+	CreatedAt time.Time      `db:"created_at"`
+	RevokedAt pq.NullTime    `db:"revoked_at"`
+}
+
+const keyCols = "id, name, prefix, hash, scopes, tier, created_at, revoked_at"
+
+// CreateKey inserts key to the client_keys table then sets its ID.
+func (repo *Repo) CreateKey(ctx context.Context, key *auth.Key) error {
+	scopes := make(pq.StringArray, len(key.Scopes))
+	for i, s := range key.Scopes {
+		scopes[i] = string(s)
+	}
+
+	q := `INSERT INTO client_keys (name, prefix, hash, scopes, tier, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+	err := repo.QueryRowContext(ctx, q, key.Name, key.Prefix, key.Hash, scopes, key.Tier, key.CreatedAt).Scan(&key.ID)
+	if err != nil {
+		return fmt.Errorf("creating client key: %v", err)
+	}
+	return nil
+}
+
+// GetKey returns the client key matching hash, or auth.ErrKeyNotFound if none.
+func (repo *Repo) GetKey(ctx context.Context, hash string) (*auth.Key, error) {
+	var row keyRow
+	err := repo.GetContext(ctx, &row, "SELECT "+keyCols+" FROM client_keys WHERE hash = $1", hash)
+	if err == sql.ErrNoRows {
+		return nil, auth.ErrKeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting client key: %v", err)
+	}
+	return row.toKey(), nil
+}
+
+// ListKeys returns the client keys, newest first.
+func (repo *Repo) ListKeys(ctx context.Context) ([]*auth.Key, error) {
+	var rows []keyRow
+	if err := repo.SelectContext(ctx, &rows, "SELECT "+keyCols+" FROM client_keys ORDER BY id DESC"); err != nil {
+		return nil, fmt.Errorf("listing client keys: %v", err)
+	}
+
+	keys := make([]*auth.Key, len(rows))
+	for i, row := range rows {
+		keys[i] = row.toKey()
+	}
+	return keys, nil
+}
+
+// RevokeKey marks the client key matching id as revoked at the supplied time,
+// or returns auth.ErrKeyNotFound if none.
+// Revoking a revoked key keeps its original revocation time.
+func (repo *Repo) RevokeKey(ctx context.Context, id int64, at time.Time) error {
+	q := "UPDATE client_keys SET revoked_at = COALESCE(revoked_at, $2) WHERE id = $1"
+	res, err := repo.ExecContext(ctx, q, id, at)
+	if err != nil {
+		return fmt.Errorf("revoking client key %d: %v", id, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("revoking client key %d: %v", id, err)
+	}
+	if n == 0 {
+		return auth.ErrKeyNotFound
+	}
+	return nil
+}
+
+func (row keyRow) toKey() *auth.Key {
+	key := &auth.Key{
+		ID:        row.ID,
+		Name:      row.Name,
+		Prefix:    row.Prefix,
+		Hash:      row.Hash,
+		Tier:      row.Tier,
+		CreatedAt: row.CreatedAt.UTC(),
+	}
+	for _, s := range row.Scopes {
+		key.Scopes = append(key.Scopes, auth.Scope(s))
+	}
+	if row.RevokedAt.Valid {
+		t := row.RevokedAt.Time.UTC()
+		key.RevokedAt = &t
+	}
+	return key
+}
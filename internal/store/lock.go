@@ -0,0 +1,73 @@
+package store
+
+// This file contains the Postgresql implementation of lock.Locker using advisory locks.
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"hash/fnv"
+
+	"github.com/riacataquian/news/internal/lock"
+)
+
+// Repo provides locks across server instances.
+var _ lock.Locker = (*Repo)(nil)
+
+// TryLock acquires the session-level Postgresql advisory lock named key without blocking.
+//
+// Advisory locks are held by a database session: the lock holds a connection
+// out of the pool until it's released.
+// The lock is also released if the connection drops, i.e., if the holder crashes.
+func (repo *Repo) TryLock(ctx context.Context, key string) (lock.Lock, bool, error) {
+	conn, err := repo.Conn(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("acquiring lock %q: %v", key, err)
+	}
+
+	id := advisoryKey(key)
+	var ok bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", id).Scan(&ok); err != nil {
+		conn.Close()
+		return nil, false, fmt.Errorf("acquiring lock %q: %v", key, err)
+	}
+	if !ok {
+		conn.Close()
+		return nil, false, nil
+	}
+
+	return &advisoryLock{conn: conn, key: key, id: id}, true, nil
+}
+
+// advisoryLock is an acquired Postgresql advisory lock.
+type advisoryLock struct {
+	conn *sql.Conn
+	key  string
+	id   int64
+}
+
+// Unlock releases the advisory lock and returns its connection to the pool.
+//
+// The connection is discarded rather than returned if releasing the lock failed,
+// so that the lock is released along with its session instead of being held by a pooled connection.
+func (l *advisoryLock) Unlock(ctx context.Context) error {
+	defer l.conn.Close()
+
+	var ok bool
+	if err := l.conn.QueryRowContext(ctx, "SELECT pg_advisory_unlock($1)", l.id).Scan(&ok); err != nil {
+		l.conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		return fmt.Errorf("releasing lock %q: %v", l.key, err)
+	}
+	if !ok {
+		return lock.ErrNotHeld
+	}
+	return nil
+}
+
+// advisoryKey hashes key to the 64-bit integer identifying an advisory lock.
+func advisoryKey(key string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return int64(h.Sum64())
+}
@@ -0,0 +1,29 @@
+package store
+
+// This file contains the check of the schema's version, as recorded by schema.sql.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// SchemaVersion is the version of schema.sql the server expects, see the schema_migrations table.
+const SchemaVersion = 2
+
+// ErrNotMigrated is the error message for a database whose schema is older than SchemaVersion.
+var ErrNotMigrated = errors.New("database schema is not migrated")
+
+// CheckMigrations returns an ErrNotMigrated if the latest version applied to the database
+// is older than SchemaVersion, i.e., schema.sql wasn't run since it changed.
+func (repo *Repo) CheckMigrations(ctx context.Context) error {
+	var version int
+	err := repo.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrNotMigrated, err)
+	}
+	if version < SchemaVersion {
+		return fmt.Errorf("%w: version %d, want %d", ErrNotMigrated, version, SchemaVersion)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package store
+
+// This file contains the Postgresql implementation of ratelimit.Store.
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/riacataquian/news/internal/ratelimit"
+)
+
+// Repo counts requests across server instances.
+var _ ratelimit.Store = (*Repo)(nil)
+
+// Incr increments the counter of bucket for the window starting at start, then returns its count.
+func (repo *Repo) Incr(ctx context.Context, bucket string, start, expires time.Time) (int, error) {
+	q := `INSERT INTO rate_limits (bucket, window_start, count, expires_at)
+		VALUES ($1, $2, 1, $3)
+		ON CONFLICT (bucket, window_start) DO UPDATE
+		SET count = rate_limits.count + 1
+		RETURNING count`
+	var count int
+	if err := repo.QueryRowContext(ctx, q, bucket, start, expires).Scan(&count); err != nil {
+		return 0, fmt.Errorf("incrementing rate limit counter %q: %v", bucket, err)
+	}
+	return count, nil
+}
+
+// Prune deletes the counters expired by now.
+func (repo *Repo) Prune(ctx context.Context, now time.Time) error {
+	if _, err := repo.ExecContext(ctx, "DELETE FROM rate_limits WHERE expires_at <= $1", now); err != nil {
+		return fmt.Errorf("pruning rate limit counters: %v", err)
+	}
+	return nil
+}
@@ -3,66 +3,121 @@ package store
 // This file contains implementations of a Postgresql store or repository.
 
 import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
 	"fmt"
+	"strings"
+	"sync"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
+	"github.com/riacataquian/news/internal/config"
+	"github.com/riacataquian/news/internal/trace"
 )
 
 // Repo is an interface to a Postgresql database.
 // Repo satisfies the Store interface.
 type Repo struct {
 	*sqlx.DB
+	connector *connector
 }
 
-// New returns a Store which is a handler to a database pool.
+// New returns a Store which is a handler to a database pool configured as per conf.
 //
 // It opens and establishes a connection to a Postgresql database pool if none is available.
-func New() *Repo {
-	conf := DBConfig{
-		Host: "localhost",
-		Port: 5432,
-		User: "",
-		Name: "news",
-	}
-	constr := fmt.Sprintf("host=%s port=%d user=%s dbname=%s sslmode=disable", conf.Host, conf.Port, conf.User, conf.Name)
-	db, err := sqlx.Open("postgres", constr) // Validates the connection string.
-	if err != nil {
-		panic(err)
-	}
+func New(conf config.Database) *Repo {
+	c := &connector{conf: conf}
+	db := sqlx.NewDb(sql.OpenDB(c), "postgres")
 
 	// Open a connection to the database.
-	err = db.Ping()
+	err := db.Ping()
 	if err != nil {
 		panic(err)
 	}
 
-	return &Repo{DB: db}
+	return &Repo{DB: db, connector: c}
+}
+
+// SetPassword replaces the database password, i.e., once its secret is reloaded.
+// New connections authenticate with it, open connections are kept.
+func (repo *Repo) SetPassword(password config.Secret) {
+	repo.connector.setPassword(password)
+}
+
+// connector opens connections to a Postgresql database as per its current configuration.
+//
+// connector implements driver.Connector.
+type connector struct {
+	mu   sync.Mutex
+	conf config.Database
+}
+
+// Connect opens a connection to the database.
+func (c *connector) Connect(_ context.Context) (driver.Conn, error) {
+	return c.Driver().Open(c.dsn())
+}
+
+// Driver returns the Postgresql driver.
+func (c *connector) Driver() driver.Driver {
+	return &pq.Driver{}
+}
+
+func (c *connector) setPassword(password config.Secret) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.conf.Password = password
+}
+
+// dsn returns the connection string of the current configuration.
+func (c *connector) dsn() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	constr := fmt.Sprintf("host=%s port=%d user=%s dbname=%s sslmode=disable", c.conf.Host, c.conf.Port, c.conf.User, c.conf.Name)
+	if c.conf.Password != "" {
+		constr += " password=" + quote(string(c.conf.Password))
+	}
+	return constr
+}
+
+// quote quotes a connection string value, escaping its quotes and backslashes.
+func quote(v string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v) + "'"
 }
 
 // Create performs Postgresql's `copy` to insert the supplied rows given a list of `cols` columns.
-func (repo *Repo) Create(table string, cols []string, rows ...Row) error {
-	tx, err := repo.Begin()
+// It's traced as a span of the trace carried by ctx, if any.
+func (repo *Repo) Create(ctx context.Context, table string, cols []string, rows ...Row) (err error) {
+	ctx, span := trace.Start(ctx, "store.Create")
+	span.SetAttribute("db.table", table)
+	span.SetAttribute("db.rows", len(rows))
+	defer func() {
+		span.RecordError(err)
+		span.End()
+	}()
+
+	tx, err := repo.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
-	stmt, err := tx.Prepare(pq.CopyIn(table, cols...))
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn(table, cols...))
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
 	for _, row := range rows {
-		_, err = stmt.Exec(row...)
+		_, err = stmt.ExecContext(ctx, row...)
 		if err != nil {
 			return fmt.Errorf("persisting rows: %v", err)
 		}
 	}
 
 	// Clear any buffered data; plan pq execution.
-	_, err = stmt.Exec()
+	_, err = stmt.ExecContext(ctx)
 	if err != nil {
 		return fmt.Errorf("clearing unbuffered data: %v", err)
 	}
@@ -0,0 +1,187 @@
+package store
+
+// This file contains the Postgresql implementation of ingestion.Store.
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/riacataquian/news/internal/ingestion"
+)
+
+// Repo records ingestion runs.
+var _ ingestion.Store = (*Repo)(nil)
+
+// runRow is an ingestion_runs entry.
+type runRow struct {
+	ID                int64       `db:"id"`
+	Job               string      `db:"job"`
+	Trigger           string      `db:"trigger"`
+	Status            string      `db:"status"`
+	StartedAt         time.Time   `db:"started_at"`
+	FinishedAt        pq.NullTime `db:"finished_at"`
+	Results           []byte      `db:"results"`
+	Error             string      `db:"error"`
+	ArticlesFetched   int         `db:"articles_fetched"`
+	ArticlesPersisted int         `db:"articles_persisted"`
+}
+
+const runCols = "id, job, trigger, status, started_at, finished_at, results, error, articles_fetched, articles_persisted"
+
+// CreateRun inserts run to the ingestion_runs table and sets its ID.
+func (repo *Repo) CreateRun(ctx context.Context, run *ingestion.Run) error {
+	results, err := json.Marshal(run.Results)
+	if err != nil {
+		return fmt.Errorf("encoding run results: %v", err)
+	}
+
+	q := `INSERT INTO ingestion_runs (job, trigger, status, started_at, results, error, articles_fetched, articles_persisted)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`
+	err = repo.QueryRowContext(ctx, q,
+		run.Job,
+		string(run.Trigger),
+		string(run.Status),
+		run.StartedAt,
+		results,
+		run.Error,
+		run.ArticlesFetched,
+		run.ArticlesPersisted,
+	).Scan(&run.ID)
+	if err != nil {
+		return fmt.Errorf("creating ingestion run: %v", err)
+	}
+	return nil
+}
+
+// UpdateRun overwrites the status, results and counts of the run matching run.ID.
+func (repo *Repo) UpdateRun(ctx context.Context, run *ingestion.Run) error {
+	results, err := json.Marshal(run.Results)
+	if err != nil {
+		return fmt.Errorf("encoding run results: %v", err)
+	}
+
+	var finished pq.NullTime
+	if run.FinishedAt != nil {
+		finished = pq.NullTime{Time: *run.FinishedAt, Valid: true}
+	}
+
+	q := `UPDATE ingestion_runs
+		SET status = $2, finished_at = $3, results = $4, error = $5, articles_fetched = $6, articles_persisted = $7
+		WHERE id = $1`
+	_, err = repo.ExecContext(ctx, q,
+		run.ID,
+		string(run.Status),
+		finished,
+		results,
+		run.Error,
+		run.ArticlesFetched,
+		run.ArticlesPersisted,
+	)
+	if err != nil {
+		return fmt.Errorf("updating ingestion run %d: %v", run.ID, err)
+	}
+	return nil
+}
+
+// ListRuns returns at most `limit` runs ordered by most recently started, skipping `offset` runs.
+// It also returns the total count of runs.
+func (repo *Repo) ListRuns(ctx context.Context, limit, offset int) ([]*ingestion.Run, int, error) {
+	var total int
+	if err := repo.GetContext(ctx, &total, "SELECT count(*) FROM ingestion_runs"); err != nil {
+		return nil, 0, fmt.Errorf("counting ingestion runs: %v", err)
+	}
+
+	var rows []runRow
+	q := "SELECT " + runCols + " FROM ingestion_runs ORDER BY started_at DESC, id DESC LIMIT $1 OFFSET $2"
+	if err := repo.SelectContext(ctx, &rows, q, limit, offset); err != nil {
+		return nil, 0, fmt.Errorf("listing ingestion runs: %v", err)
+	}
+
+	runs := make([]*ingestion.Run, 0, len(rows))
+	for _, row := range rows {
+		run, err := row.toRun()
+		if err != nil {
+			return nil, 0, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, total, nil
+}
+
+// GetRun returns the run matching the supplied ID, or ingestion.ErrRunNotFound if none.
+func (repo *Repo) GetRun(ctx context.Context, id int64) (*ingestion.Run, error) {
+	var row runRow
+	q := "SELECT " + runCols + " FROM ingestion_runs WHERE id = $1"
+	err := repo.GetContext(ctx, &row, q, id)
+	if err == sql.ErrNoRows {
+		return nil, ingestion.ErrRunNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting ingestion run %d: %v", id, err)
+	}
+	return row.toRun()
+}
+
+// LatestRun returns the most recently started run matching filter, or ingestion.ErrRunNotFound if none.
+func (repo *Repo) LatestRun(ctx context.Context, filter ingestion.Filter) (*ingestion.Run, error) {
+	var (
+		conds []string
+		args  []interface{}
+	)
+	if filter.Job != "" {
+		args = append(args, filter.Job)
+		conds = append(conds, fmt.Sprintf("job = $%d", len(args)))
+	}
+	if filter.Trigger != "" {
+		args = append(args, string(filter.Trigger))
+		conds = append(conds, fmt.Sprintf("trigger = $%d", len(args)))
+	}
+	if filter.Status != "" {
+		args = append(args, string(filter.Status))
+		conds = append(conds, fmt.Sprintf("status = $%d", len(args)))
+	}
+
+	q := "SELECT " + runCols + " FROM ingestion_runs"
+	if len(conds) > 0 {
+		q += " WHERE " + strings.Join(conds, " AND ")
+	}
+	q += " ORDER BY started_at DESC, id DESC LIMIT 1"
+
+	var row runRow
+	err := repo.GetContext(ctx, &row, q, args...)
+	if err == sql.ErrNoRows {
+		return nil, ingestion.ErrRunNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting latest ingestion run: %v", err)
+	}
+	return row.toRun()
+}
+
+func (row runRow) toRun() (*ingestion.Run, error) {
+	run := &ingestion.Run{
+		ID:                row.ID,
+		Job:               row.Job,
+		Trigger:           ingestion.Trigger(row.Trigger),
+		Status:            ingestion.Status(row.Status),
+		StartedAt:         row.StartedAt.UTC(),
+		Error:             row.Error,
+		ArticlesFetched:   row.ArticlesFetched,
+		ArticlesPersisted: row.ArticlesPersisted,
+	}
+	if row.FinishedAt.Valid {
+		finished := row.FinishedAt.Time.UTC()
+		run.FinishedAt = &finished
+	}
+	if len(row.Results) > 0 {
+		if err := json.Unmarshal(row.Results, &run.Results); err != nil {
+			return nil, fmt.Errorf("decoding results of ingestion run %d: %v", row.ID, err)
+		}
+	}
+	return run, nil
+}
@@ -0,0 +1,32 @@
+package trace
+
+// This file contains the exporters of spans.
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// WriterExporter writes spans to an io.Writer as JSON lines, i.e., to stdout to inspect traces locally.
+type WriterExporter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterExporter returns a WriterExporter writing to w.
+func NewWriterExporter(w io.Writer) *WriterExporter {
+	return &WriterExporter{w: w}
+}
+
+// Export implements Exporter.
+func (e *WriterExporter) Export(s *SpanData) {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.w.Write(append(b, '\n'))
+}
@@ -0,0 +1,197 @@
+// Package trace records spans of work, OpenTelemetry style, and exports them once ended.
+//
+// Spans propagate through context.Context arguments: Start starts a child of the span carried by ctx, if any,
+// otherwise a root span, exported by the Tracer carried by ctx, see NewContext.
+// Without a Tracer, spans are no-ops, so that instrumented code needn't know whether tracing is enabled.
+package trace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// Statuses of an ended span.
+const (
+	StatusOK    = "ok"
+	StatusError = "error"
+)
+
+// SpanData is an ended span, as exported.
+type SpanData struct {
+	TraceID      string                 `json:"traceId"`
+	SpanID       string                 `json:"spanId"`
+	ParentSpanID string                 `json:"parentSpanId,omitempty"`
+	Name         string                 `json:"name"`
+	Start        time.Time              `json:"start"`
+	End          time.Time              `json:"end"`
+	DurationMs   float64                `json:"durationMs"`
+	Attributes   map[string]interface{} `json:"attributes,omitempty"`
+	Status       string                 `json:"status"`
+	Error        string                 `json:"error,omitempty"`
+}
+
+// Exporter exports ended spans, i.e., to stdout. It must be safe for concurrent use.
+type Exporter interface {
+	Export(*SpanData)
+}
+
+// Tracer starts root spans then exports the spans of their traces once ended.
+type Tracer struct {
+	exporter Exporter
+	// now is the time spans start and end at, overridable in tests.
+	now func() time.Time
+}
+
+// NewTracer returns a Tracer exporting spans to e.
+func NewTracer(e Exporter) *Tracer {
+	return &Tracer{exporter: e, now: time.Now}
+}
+
+// Span is a span of work of a trace. Its methods are safe for concurrent use, and no-ops for a span without Tracer.
+type Span struct {
+	tracer *Tracer
+
+	mu    sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+type (
+	tracerContextKey struct{}
+	spanContextKey   struct{}
+)
+
+// NewContext returns a copy of ctx carrying t, which exports the spans started from it.
+func NewContext(ctx context.Context, t *Tracer) context.Context {
+	return context.WithValue(ctx, tracerContextKey{}, t)
+}
+
+// TracerFromContext returns the Tracer carried by ctx, if any.
+func TracerFromContext(ctx context.Context) (*Tracer, bool) {
+	t, ok := ctx.Value(tracerContextKey{}).(*Tracer)
+	return t, ok
+}
+
+// FromContext returns the span carried by ctx, if any.
+func FromContext(ctx context.Context) (*Span, bool) {
+	s, ok := ctx.Value(spanContextKey{}).(*Span)
+	return s, ok
+}
+
+// Start starts a span of name, child of the span carried by ctx if any, and returns a copy of ctx carrying it.
+// The span must be ended, see Span.End.
+func Start(ctx context.Context, name string) (context.Context, *Span) {
+	parent, ok := FromContext(ctx)
+	if ok && parent.tracer != nil {
+		return start(ctx, parent.tracer, parent.data.TraceID, parent.data.SpanID, name)
+	}
+
+	t, ok := TracerFromContext(ctx)
+	if !ok {
+		return ctx, &Span{}
+	}
+	return start(ctx, t, newID(16), "", name)
+}
+
+// StartRemote starts a span of name whose parent is the remote span of the W3C traceparent header, if valid,
+// i.e., the span of a proxy or of a client. Otherwise, it starts a span as per Start.
+func StartRemote(ctx context.Context, traceparent, name string) (context.Context, *Span) {
+	t, ok := TracerFromContext(ctx)
+	m := traceparentRe.FindStringSubmatch(traceparent)
+	if !ok || m == nil || m[1] == zeroTraceID || m[2] == zeroSpanID {
+		return Start(ctx, name)
+	}
+	return start(ctx, t, m[1], m[2], name)
+}
+
+// traceparentRe matches W3C traceparent headers of version 00, see https://www.w3.org/TR/trace-context/.
+var traceparentRe = regexp.MustCompile(`^00-([0-9a-f]{32})-([0-9a-f]{16})-[0-9a-f]{2}$`)
+
+const (
+	zeroTraceID = "00000000000000000000000000000000"
+	zeroSpanID  = "0000000000000000"
+)
+
+func start(ctx context.Context, t *Tracer, traceID, parentID, name string) (context.Context, *Span) {
+	s := &Span{
+		tracer: t,
+		data: SpanData{
+			TraceID:      traceID,
+			SpanID:       newID(8),
+			ParentSpanID: parentID,
+			Name:         name,
+			Start:        t.now(),
+			Status:       StatusOK,
+		},
+	}
+	return context.WithValue(ctx, spanContextKey{}, s), s
+}
+
+// TraceID returns the ID of the span's trace, empty for a span without Tracer.
+func (s *Span) TraceID() string {
+	return s.data.TraceID
+}
+
+// SetAttribute sets the attribute key of the span to value, i.e., the encoded parameters of a request.
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s.tracer == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.data.Attributes == nil {
+		s.data.Attributes = make(map[string]interface{})
+	}
+	s.data.Attributes[key] = value
+}
+
+// RecordError marks the span as failed with err, if not nil.
+func (s *Span) RecordError(err error) {
+	if s.tracer == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Status = StatusError
+	s.data.Error = err.Error()
+}
+
+// End ends the span then exports it. Ending a span twice is a no-op.
+func (s *Span) End() {
+	if s.tracer == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = s.tracer.now()
+	s.data.DurationMs = float64(s.data.End.Sub(s.data.Start)) / float64(time.Millisecond)
+	data := s.data
+	data.Attributes = make(map[string]interface{}, len(s.data.Attributes))
+	for k, v := range s.data.Attributes {
+		data.Attributes[k] = v
+	}
+	s.mu.Unlock()
+
+	if s.tracer.exporter != nil {
+		s.tracer.exporter.Export(&data)
+	}
+}
+
+// newID returns n random bytes, hex encoded.
+func newID(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand only fails when the OS can't provide randomness, the span is still recorded.
+		return fmt.Sprintf("%0*x", n*2, time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
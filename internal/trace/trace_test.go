@@ -0,0 +1,164 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+// fakeExporter records the exported spans.
+type fakeExporter struct {
+	mu    sync.Mutex
+	spans []*SpanData
+}
+
+func (e *fakeExporter) Export(s *SpanData) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, s)
+}
+
+var epoch = time.Date(2018, time.July, 28, 10, 0, 0, 0, time.UTC)
+
+// newTestTracer returns a Tracer exporting to e whose clock ticks by 10ms per reading.
+func newTestTracer(e Exporter) *Tracer {
+	now := epoch
+	t := NewTracer(e)
+	t.now = func() time.Time {
+		defer func() { now = now.Add(10 * time.Millisecond) }()
+		return now
+	}
+	return t
+}
+
+func TestStart(t *testing.T) {
+	t.Parallel()
+
+	e := &fakeExporter{}
+	ctx := NewContext(context.Background(), newTestTracer(e))
+
+	ctx, parent := Start(ctx, "middleware")
+	_, child := Start(ctx, "newsclient.Get")
+	child.SetAttribute("newsapi.params", "q=bitcoin")
+	child.RecordError(errors.New("upstream is down"))
+	child.End()
+	child.End()
+	parent.End()
+
+	if len(e.spans) != 2 {
+		t.Fatalf("End(): want 2 exported spans, got %d", len(e.spans))
+	}
+	got, root := e.spans[0], e.spans[1]
+	if root.ParentSpanID != "" || len(root.TraceID) != 32 || len(root.SpanID) != 16 {
+		t.Errorf("Start(_, _): want a root span with a 32 hex trace ID and 16 hex span ID, got %+v", root)
+	}
+
+	want := &SpanData{
+		TraceID:      root.TraceID,
+		SpanID:       got.SpanID,
+		ParentSpanID: root.SpanID,
+		Name:         "newsclient.Get",
+		Start:        epoch.Add(10 * time.Millisecond),
+		End:          epoch.Add(20 * time.Millisecond),
+		DurationMs:   10,
+		Attributes:   map[string]interface{}{"newsapi.params": "q=bitcoin"},
+		Status:       StatusError,
+		Error:        "upstream is down",
+	}
+	if diff := pretty.Compare(got, want); diff != "" {
+		t.Errorf("Start(_, _) diff: (-got +want)\n%s", diff)
+	}
+}
+
+func TestStartRemote(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc        string
+		traceparent string
+		wantTraceID string
+		wantParent  string
+	}{
+		{
+			desc:        "continues the trace of a valid traceparent",
+			traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			wantTraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+			wantParent:  "00f067aa0ba902b7",
+		},
+		{
+			desc:        "starts a root span for an invalid traceparent",
+			traceparent: "00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		},
+		{
+			desc: "starts a root span without traceparent",
+		},
+	}
+
+	for _, test := range tests {
+		e := &fakeExporter{}
+		ctx := NewContext(context.Background(), newTestTracer(e))
+		_, span := StartRemote(ctx, test.traceparent, "GET /api/list")
+		span.End()
+
+		got := e.spans[0]
+		if test.wantTraceID != "" && got.TraceID != test.wantTraceID {
+			t.Errorf("%s: StartRemote(_, %q, _): want trace ID %q, got %q", test.desc, test.traceparent, test.wantTraceID, got.TraceID)
+		}
+		if test.wantTraceID == "" && (len(got.TraceID) != 32 || got.TraceID == zeroTraceID) {
+			t.Errorf("%s: StartRemote(_, %q, _): want a new trace ID, got %q", test.desc, test.traceparent, got.TraceID)
+		}
+		if got.ParentSpanID != test.wantParent {
+			t.Errorf("%s: StartRemote(_, %q, _): want parent span ID %q, got %q", test.desc, test.traceparent, test.wantParent, got.ParentSpanID)
+		}
+	}
+}
+
+func TestStartWithoutTracer(t *testing.T) {
+	t.Parallel()
+
+	ctx, span := Start(context.Background(), "store.Create")
+	span.SetAttribute("db.table", "news")
+	span.RecordError(errors.New("some error"))
+	span.End()
+
+	if _, ok := FromContext(ctx); ok {
+		t.Errorf("Start(_, _): want no span carried by the context without tracer")
+	}
+	if got := span.TraceID(); got != "" {
+		t.Errorf("TraceID(): want an empty trace ID without tracer, got %q", got)
+	}
+}
+
+func TestWriterExporter(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	ctx := NewContext(context.Background(), newTestTracer(NewWriterExporter(&buf)))
+	_, span := Start(ctx, "store.Create")
+	span.SetAttribute("db.rows", 2)
+	span.End()
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("Export(_): want a JSON line, got %q: %v", buf.String(), err)
+	}
+	want := map[string]interface{}{
+		"traceId":    span.TraceID(),
+		"spanId":     got["spanId"],
+		"name":       "store.Create",
+		"start":      "2018-07-28T10:00:00Z",
+		"end":        "2018-07-28T10:00:00.01Z",
+		"durationMs": float64(10),
+		"attributes": map[string]interface{}{"db.rows": float64(2)},
+		"status":     StatusOK,
+	}
+	if diff := pretty.Compare(got, want); diff != "" {
+		t.Errorf("Export(_) diff: (-got +want)\n%s", diff)
+	}
+}
@@ -0,0 +1,137 @@
+// Package validation collects the problems of request parameters per field,
+// so that API consumers can highlight the offending form fields.
+package validation
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/riacataquian/news/internal/httperror"
+
+	"github.com/gorilla/schema"
+)
+
+// Validator describes request parameters that validate themselves.
+type Validator interface {
+	// Validate adds the problems of each invalid field to errs.
+	Validate(errs *Errors)
+}
+
+// Errors collects the problems of each field, in the order they're added.
+//
+// It satisfies the error interface.
+type Errors struct {
+	fields []string
+	errs   map[string][]string
+}
+
+// Add adds a problem of field.
+func (e *Errors) Add(field, format string, args ...interface{}) {
+	if e.errs == nil {
+		e.errs = make(map[string][]string)
+	}
+	if _, ok := e.errs[field]; !ok {
+		e.fields = append(e.fields, field)
+	}
+	e.errs[field] = append(e.errs[field], fmt.Sprintf(format, args...))
+}
+
+// Len returns the count of invalid fields.
+func (e *Errors) Len() int {
+	return len(e.fields)
+}
+
+// FieldErrs returns the problems of each field.
+func (e *Errors) FieldErrs() []httperror.FieldErr {
+	var errs []httperror.FieldErr
+	for _, f := range e.fields {
+		errs = append(errs, httperror.FieldErr{Field: f, Errors: e.errs[f]})
+	}
+	return errs
+}
+
+// Error formats the problems of each field.
+func (e *Errors) Error() string {
+	var s []string
+	for _, f := range e.fields {
+		s = append(s, fmt.Sprintf("%s: %s", f, strings.Join(e.errs[f], ", ")))
+	}
+	return strings.Join(s, "; ")
+}
+
+// Decode decodes the src values to dst, then validates dst if it's a Validator.
+//
+// It returns an HTTPError with status 422 that lists every problem per field:
+// unknown parameters, values of the wrong type and the ones found by the Validator.
+func Decode(dst interface{}, src url.Values, r *http.Request) error {
+	errs := &Errors{}
+	err := schema.NewDecoder().Decode(dst, src)
+	switch v := err.(type) {
+	case nil:
+	case schema.MultiError:
+		addDecodeErrors(errs, v)
+	default:
+		return fmt.Errorf("error decoding params: %v", err)
+	}
+
+	// Fields that failed decoding are zero, validate the others only.
+	if v, ok := dst.(Validator); ok && errs.Len() == 0 {
+		v.Validate(errs)
+	}
+
+	if errs.Len() == 0 {
+		return nil
+	}
+	return &httperror.HTTPError{
+		Type:       httperror.TypeValidation,
+		Code:       http.StatusUnprocessableEntity,
+		Message:    "invalid parameters",
+		RequestURL: r.RequestURI,
+		FieldErrors: []httperror.FieldErrors{
+			{
+				Message: "invalid parameters",
+				Errors:  errs.FieldErrs(),
+			},
+		},
+	}
+}
+
+// addDecodeErrors adds the errors of schema's decoding to errs, sorted by field.
+func addDecodeErrors(errs *Errors, m schema.MultiError) {
+	var fields []string
+	for f := range m {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+
+	for _, f := range fields {
+		v, ok := m[f].(schema.ConversionError)
+		switch {
+		case !ok:
+			errs.Add(f, "unknown parameter")
+		case v.Type == nil:
+			errs.Add(f, "is invalid")
+		default:
+			errs.Add(f, "must be %s", describe(v.Type))
+		}
+	}
+}
+
+// describe describes values of type t, i.e., "an integer".
+func describe(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Bool:
+		return "a boolean"
+	default:
+		return "a " + t.String()
+	}
+}
@@ -0,0 +1,91 @@
+package validation
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+	"github.com/riacataquian/news/internal/httperror"
+)
+
+type fakeparams struct {
+	Query    string `schema:"query"`
+	PageSize int    `schema:"pageSize"`
+	Async    bool   `schema:"async"`
+}
+
+func (p *fakeparams) Validate(errs *Errors) {
+	if p.Query == "" {
+		errs.Add("query", "is required")
+	}
+	if p.PageSize > 100 {
+		errs.Add("pageSize", "must be at most %d", 100)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		desc string
+		in   url.Values
+		want []httperror.FieldErr
+	}{
+		{
+			desc: "decodes valid params",
+			in:   url.Values{"query": {"bitcoin"}, "pageSize": {"10"}},
+		},
+		{
+			desc: "collects unknown params and wrong types",
+			in:   url.Values{"query": {"bitcoin"}, "pageSize": {"ten"}, "async": {"maybe"}, "foo": {"bar"}},
+			want: []httperror.FieldErr{
+				{Field: "async", Errors: []string{"must be a boolean"}},
+				{Field: "foo", Errors: []string{"unknown parameter"}},
+				{Field: "pageSize", Errors: []string{"must be an integer"}},
+			},
+		},
+		{
+			desc: "collects the validator's problems",
+			in:   url.Values{"pageSize": {"1000"}},
+			want: []httperror.FieldErr{
+				{Field: "query", Errors: []string{"is required"}},
+				{Field: "pageSize", Errors: []string{"must be at most 100"}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/list?"+test.in.Encode(), nil)
+		err := Decode(&fakeparams{}, test.in, r)
+		if test.want == nil {
+			if err != nil {
+				t.Errorf("%s: Decode(_, %v, _): want nil, got %v", test.desc, test.in, err)
+			}
+			continue
+		}
+
+		v, ok := err.(*httperror.HTTPError)
+		if !ok || v.Code != http.StatusUnprocessableEntity || v.Type != httperror.TypeValidation || len(v.FieldErrors) != 1 {
+			t.Fatalf("%s: Decode(_, %v, _): want a 422 HTTPError with field errors, got %v", test.desc, test.in, err)
+		}
+		if diff := pretty.Compare(v.FieldErrors[0].Errors, test.want); diff != "" {
+			t.Errorf("%s: Decode(_, %v, _) diff: (-got +want)\n%s", test.desc, test.in, diff)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	errs := &Errors{}
+	errs.Add("country", "cannot be mixed with sources")
+	errs.Add("pageSize", "must be between 1 and %d", 100)
+	errs.Add("country", "must be a 2-letter code")
+
+	if got := errs.Len(); got != 2 {
+		t.Errorf("Len(): want 2, got %d", got)
+	}
+
+	want := "country: cannot be mixed with sources, must be a 2-letter code; pageSize: must be between 1 and 100"
+	if got := errs.Error(); got != want {
+		t.Errorf("Error(): want %q, got %q", want, got)
+	}
+}
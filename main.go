@@ -4,13 +4,29 @@ package main // import "github.com/riacataquian/news"
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
 	"io"
 	"log"
+	"math"
 	"net/http"
 	"os"
+	"strconv"
 
+	"github.com/riacataquian/news/internal/app"
+	"github.com/riacataquian/news/internal/auth"
+	"github.com/riacataquian/news/internal/compress"
+	"github.com/riacataquian/news/internal/config"
+	"github.com/riacataquian/news/internal/cors"
+	"github.com/riacataquian/news/internal/health"
 	"github.com/riacataquian/news/internal/httperror"
+	"github.com/riacataquian/news/internal/logging"
+	"github.com/riacataquian/news/internal/metrics"
+	"github.com/riacataquian/news/internal/ratelimit"
 	"github.com/riacataquian/news/internal/store"
+	"github.com/riacataquian/news/internal/trace"
+	"github.com/riacataquian/news/web/cron"
 	"github.com/riacataquian/news/web/handler"
 
 	"github.com/gorilla/mux"
@@ -22,38 +38,156 @@ const (
 )
 
 // main starts a web server and register routes and their matching handlers.
+// It authenticates requests with the client keys stored in the data repository, see cmd/apikey,
+// then rate limits them as per their key's tier.
 // It injects a context.Context argument for the route handlers to allow deadline and cancelation among HTTP requests.
-// It also injects a data repository handler to be consumed by the HTTP handlers.
+// It also injects an app.App, holding the configuration, the data repository and the newsapi clients, to the HTTP handlers.
 // Finally, it marshals successful and error JSON responses.
+//
+// It also schedules the cron jobs. Replicas sharing the database run a given job once per tick.
+//
+// It's configured as per config.Load, see README.md.
+// On SIGINT or SIGTERM, it drains the in-flight requests, stops the cron jobs then closes the database pool.
 func main() {
-	serve(context.Background(), store.New())
+	conf, err := config.Load(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("configuration:\n%s", conf)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if conf.Tracing.Exporter == "stdout" {
+		// The requests and the cron jobs trace their spans with the tracer of ctx, see trace.Start.
+		ctx = trace.NewContext(ctx, trace.NewTracer(trace.NewWriterExporter(os.Stdout)))
+	}
+	repo := store.New(conf.Database)
+
+	limiter, err := newLimiter(conf.RateLimit, repo)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// The handlers and the cron jobs share the newsapi keys and their usage.
+	keys := auth.NewKeyPool(conf.NewsAPI.APIKeys(), conf.NewsAPI.Cooldown.Duration)
+	if len(keys.Usage()) == 0 {
+		log.Fatal(auth.ErrMissingAPIKey)
+	}
+	a := app.New(conf, repo, keys)
+	registerMetrics(a, repo)
+
+	// Secrets are reloaded on SIGHUP or when their files change, without restarting.
+	reloader := auth.NewReloader(a.Config.Secrets.Interval.Duration, a.Config.Secrets.APIKeysFile, a.Config.Secrets.APIKeyFile, a.Config.Secrets.DBPasswordFile)
+	reloader.OnReload(func() error {
+		fresh, err := config.Load(flag.NewFlagSet(os.Args[0], flag.ContinueOnError), os.Args[1:])
+		if err != nil {
+			return err
+		}
+		repo.SetPassword(fresh.Database.Password)
+		return keys.Reload(fresh.NewsAPI.APIKeys(), fresh.NewsAPI.Cooldown.Duration)
+	})
+	reloader.Start()
+
+	sched := cron.NewScheduler(a, repo, cron.Schedule{Job: cron.ListJob, Every: conf.Cron.Interval.Duration})
+	sched.Start(ctx)
+
+	err = serve(ctx, a, limiter, readiness(a, repo))
+
+	// Cancel the requests that outlived the shutdown deadline along with the running cron jobs.
+	cancel()
+	sched.Stop()
+	// The jobs started from /admin/cron/{job}/run record their runs before the pool is closed.
+	cron.Wait()
+	reloader.Stop()
+	if cerr := repo.Close(); cerr != nil {
+		log.Printf("error closing the database pool: %v", cerr)
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Print("server stopped")
 }
 
-func serve(ctx context.Context, repo store.Store) {
-	srv := mux.NewRouter().PathPrefix("/api").Subrouter()
-	for _, route := range handler.Routes {
-		srv.Handle(route.Path, middleware(ctx, repo, route.HandlerFunc))
+// newLimiter returns a rate limiter as per conf, counting requests in repo
+// unless its store is memory, i.e., for a single instance.
+func newLimiter(conf config.RateLimit, repo *store.Repo) (*ratelimit.Limiter, error) {
+	tiers, err := ratelimit.ParseTiers(conf.Tiers)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := http.ListenAndServe(":8000", srv); err != nil {
-		log.Fatalf("could not listen to port 8000: %v", err)
+	if conf.Store == "memory" {
+		return ratelimit.New(ratelimit.NewMemory(), tiers), nil
 	}
+	return ratelimit.New(repo, tiers), nil
+}
+
+// serve serves the routes of each of the handler.Versions under their prefix, i.e., /api/v1,
+// and the unversioned routes under /api, an alias of v1 announcing its deprecation, see handler.Unversioned,
+// the OpenAPI document of the latest version at /api/openapi.json, the metrics at /metrics in Prometheus' text format,
+// and the liveness and readiness probes at /healthz and /readyz, running checks,
+// as per a's configuration until the server is shut down, see listenAndServe.
+// Browser clients of the allowed origins call /api as per the CORS policy, see cors.Policy.Middleware.
+// Responses are compressed as negotiated by the clients, see compress.Handler.
+func serve(ctx context.Context, a *app.App, limiter *ratelimit.Limiter, checks []health.Check) error {
+	// Responses are compressed within the access log, so that it logs the bytes sent.
+	compressed := func(h http.Handler) http.Handler {
+		return compress.Handler(a.Config.Server.CompressMinSize, h)
+	}
+
+	router := mux.NewRouter()
+	router.Handle("/metrics", compressed(metrics.Default.Handler()))
+	router.Handle("/healthz", compressed(health.Live()))
+	router.Handle("/readyz", compressed(health.Ready(checks...)))
+	router.Handle("/api/openapi.json", compressed(handler.OpenAPISpec()))
+
+	// Versions are mounted before the unversioned routes, so that their prefixes take precedence over /api.
+	versions := append([]handler.Version{}, handler.Versions...)
+	versions = append(versions, handler.Unversioned(a.Config.Server.UnversionedSunsetDate()))
+	policy := cors.New(a.Config.CORS)
+	for _, v := range versions {
+		srv := router.PathPrefix(v.Prefix()).Subrouter()
+		srv.Use(policy.Middleware, v.Middleware)
+		for _, route := range v.Routes {
+			srv.Handle(route.Path, accessLog(compressed(middleware(ctx, a, limiter, route.Scope, route.CacheControl, route.HandlerFunc))))
+		}
+	}
+
+	return listenAndServe(newServer(a.Config.Server, router), a.Config.Server)
 }
 
 // middleware transforms a handler.Func to http.HandlerFunc.
 //
 // The middleware does the repetitive yet necessary calculations for a handler:
-// 1. Sets the response's content-type to application/json.
-// 2. Sets the supplied status code in the response's header then finally encode the response for JSON rendering.
-// 3. When an error is encountered, sets the proper response header, given an httperror or the DefaultErrStatusCode.
-func middleware(ctx context.Context, repo store.Store, h handler.Func) http.HandlerFunc {
+// 1. Injects the request-scoped logger, see accessLog, to the handler's context.
+// 2. Sets the response's content-type to application/json.
+// 3. Authenticates the request with a client key granted scope, unless auth.Public, injecting the key to the handler's context.
+// 4. Rate limits the request as per the key's tier.
+// 5. Sets the supplied status code in the response's header then finally encode the response for JSON rendering,
+// along with its caching headers as per cacheControl, answering 304 if the client's copy is fresh, see writeCached.
+// 6. When an error is encountered, sets the proper response header, given an httperror or the DefaultErrStatusCode,
+// then encodes it as RFC 7807 problem details if the request accepts application/problem+json.
+func middleware(ctx context.Context, a *app.App, limiter *ratelimit.Limiter, scope auth.Scope, cacheControl string, h handler.Func) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := logging.NewContext(ctx, logging.FromContext(r.Context()))
 		w.Header().Set("content-type", "application/json")
 
-		resp, err := h(ctx, repo, r)
+		// Spans of the request are children of the client's span, if it sent a traceparent header.
+		ctx, span := trace.StartRemote(ctx, r.Header.Get("traceparent"), r.Method+" "+routeTemplate(r))
+		defer span.End()
+		if id := span.TraceID(); id != "" {
+			logging.FromContext(ctx).Set("traceId", id)
+		}
+		span.SetAttribute("http.method", r.Method)
+		span.SetAttribute("http.route", routeTemplate(r))
+
+		resp, err := authenticate(ctx, a, scope, r, rateLimit(limiter, w, h))
 		if err == nil {
-			w.WriteHeader(resp.Code)
-			encode(w, resp)
+			span.SetAttribute("http.status_code", resp.Code)
+			if resp.Links != nil {
+				w.Header().Add("link", resp.Links.Header())
+			}
+			writeCached(ctx, w, r, cacheControl, resp)
 			return
 		}
 
@@ -63,16 +197,102 @@ func middleware(ctx context.Context, repo store.Store, h handler.Func) http.Hand
 		} else {
 			code = DefaultErrStatusCode
 		}
+		span.SetAttribute("http.status_code", code)
+		span.RecordError(err)
+		w.Header().Set("cache-control", handler.NoStore)
+
+		if code == http.StatusUnauthorized {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+		}
+
+		// Clients opt in to RFC 7807 problem details with their Accept header.
+		if httperror.AcceptsProblem(r) {
+			w.Header().Set("content-type", httperror.ProblemContentType)
+			w.WriteHeader(code)
+			encode(ctx, w, httperror.NewProblem(err))
+			return
+		}
 
 		w.WriteHeader(code)
-		encode(w, err)
+		encode(ctx, w, err)
+	}
+}
+
+// authenticate calls h if scope is auth.Public or if r is authenticated with a client key granted scope,
+// otherwise it returns an HTTPError: 401 for missing, unknown or revoked keys, 403 for keys lacking scope.
+func authenticate(ctx context.Context, a *app.App, scope auth.Scope, r *http.Request, h handler.Func) (*handler.SuccessResponse, error) {
+	if scope == auth.Public {
+		return h(ctx, a, r)
+	}
+
+	keys, ok := a.Store.(auth.KeyStore)
+	if !ok {
+		return nil, errors.New("data repository does not store client keys")
+	}
+
+	key, err := auth.Authenticate(ctx, keys, r, scope)
+	switch err {
+	case nil:
+		logging.FromContext(ctx).Set("clientId", key.ID)
+		return h(auth.NewContext(ctx, key), a, r)
+	case auth.ErrMissingKey, auth.ErrInvalidKey, auth.ErrKeyRevoked:
+		return nil, &httperror.HTTPError{
+			Code:       http.StatusUnauthorized,
+			Message:    err.Error(),
+			RequestURL: r.RequestURI,
+		}
+	case auth.ErrInsufficientScope:
+		return nil, &httperror.HTTPError{
+			Code:       http.StatusForbidden,
+			Message:    err.Error(),
+			RequestURL: r.RequestURI,
+		}
+	default:
+		return nil, fmt.Errorf("error authenticating request: %v", err)
 	}
 }
 
 // encode encodes `r` to `w` as JSON responses.
-func encode(w io.Writer, r interface{}) {
+// Failures are logged with the logger of ctx: the status is already written, there's nothing left to send.
+func encode(ctx context.Context, w io.Writer, r interface{}) {
 	if err := json.NewEncoder(w).Encode(r); err != nil {
-		log.Fatalf("error marshalling response: %v", err)
-		os.Exit(1)
+		logging.FromContext(ctx).Printf("error marshalling response: %v", err)
+	}
+}
+
+// rateLimit wraps h, counting the requests of the authenticated client key against its tier's limits.
+// It sets the X-RateLimit-* headers of w, and returns a 429 HTTPError with a Retry-After header
+// once a limit is exceeded.
+func rateLimit(limiter *ratelimit.Limiter, w http.ResponseWriter, h handler.Func) handler.Func {
+	return func(ctx context.Context, a *app.App, r *http.Request) (*handler.SuccessResponse, error) {
+		key, ok := auth.FromContext(ctx)
+		if !ok {
+			return h(ctx, a, r)
+		}
+
+		res, err := limiter.Allow(ctx, fmt.Sprintf("key:%d", key.ID), key.Tier)
+		if err != nil {
+			// Serve the request rather than failing it when the counters are unavailable.
+			logging.FromContext(ctx).Printf("error rate limiting request: %v", err)
+			return h(ctx, a, r)
+		}
+
+		if res.Limit > 0 {
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(res.Reset.Unix(), 10))
+		}
+		if !res.Allowed {
+			retry := int(math.Ceil(res.RetryAfter.Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(retry))
+			return nil, &httperror.HTTPError{
+				Type:       httperror.TypeRateLimited,
+				Code:       http.StatusTooManyRequests,
+				Message:    fmt.Sprintf("rate limit of %d requests exceeded, retry in %d seconds", res.Limit, retry),
+				RequestURL: r.RequestURI,
+			}
+		}
+
+		return h(ctx, a, r)
 	}
 }
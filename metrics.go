@@ -0,0 +1,62 @@
+package main
+
+// This file contains the metrics read from the database pool and the newsapi keys.
+
+import (
+	"github.com/riacataquian/news/internal/app"
+	"github.com/riacataquian/news/internal/metrics"
+	"github.com/riacataquian/news/internal/store"
+)
+
+// registerMetrics registers the stats of repo's connection pool and the usage of a's newsapi keys
+// to metrics.Default, read whenever the metrics are scraped.
+func registerMetrics(a *app.App, repo *store.Repo) {
+	metrics.Default.GaugeFunc("news_db_connections",
+		"Count of connections of the database pool, per state: in_use or idle.",
+		func() []metrics.Sample {
+			stats := repo.Stats()
+			return []metrics.Sample{
+				{Values: []string{"in_use"}, Value: float64(stats.InUse)},
+				{Values: []string{"idle"}, Value: float64(stats.Idle)},
+			}
+		}, "state")
+	metrics.Default.CounterFunc("news_db_wait_total",
+		"Count of connections waited for, once the database pool is exhausted.",
+		func() []metrics.Sample {
+			return []metrics.Sample{{Value: float64(repo.Stats().WaitCount)}}
+		})
+	metrics.Default.CounterFunc("news_db_wait_seconds_total",
+		"Time spent waiting for connections, once the database pool is exhausted.",
+		func() []metrics.Sample {
+			return []metrics.Sample{{Value: repo.Stats().WaitDuration.Seconds()}}
+		})
+
+	// newsapi doesn't report its quota, the remaining quota is the keys that are neither quarantined nor disabled.
+	metrics.Default.GaugeFunc("news_upstream_keys",
+		"Count of newsapi keys, per state: available, quarantined, i.e., exhausted or rate limited, or removed.",
+		func() []metrics.Sample {
+			counts := a.APIKeys.Counts()
+			return []metrics.Sample{
+				{Values: []string{"available"}, Value: float64(counts.Available)},
+				{Values: []string{"quarantined"}, Value: float64(counts.Quarantined)},
+				{Values: []string{"removed"}, Value: float64(counts.Removed)},
+			}
+		}, "state")
+	metrics.Default.CounterFunc("news_upstream_key_requests_total",
+		"Count of requests made with each newsapi key, per masked key.",
+		func() []metrics.Sample {
+			// Keys sharing their masked prefix are summed, so that each series is unique.
+			var samples []metrics.Sample
+			index := make(map[string]int)
+			for _, u := range a.APIKeys.Usage() {
+				i, ok := index[u.Key]
+				if !ok {
+					i = len(samples)
+					index[u.Key] = i
+					samples = append(samples, metrics.Sample{Values: []string{u.Key}})
+				}
+				samples[i].Value += float64(u.Requests)
+			}
+			return samples
+		}, "key")
+}
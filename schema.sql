@@ -1,4 +1,4 @@
-DROP TABLE IF EXISTS News, Source;
+DROP TABLE IF EXISTS News, Source, ingestion_runs, backfill_checkpoints, client_keys, rate_limits, schema_migrations;
 
 CREATE TABLE News (
   app_id int,
@@ -17,3 +17,63 @@ CREATE TABLE Source (
   id varchar(100),
   name varchar(255)
 );
+
+CREATE TABLE ingestion_runs (
+  id bigserial,
+  job varchar(100) NOT NULL,
+  trigger varchar(50) NOT NULL,
+  status varchar(50) NOT NULL,
+  started_at TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+  finished_at TIMESTAMP WITHOUT TIME ZONE,
+  results jsonb NOT NULL DEFAULT '[]',
+  error text NOT NULL DEFAULT '',
+  articles_fetched int NOT NULL DEFAULT 0,
+  articles_persisted int NOT NULL DEFAULT 0,
+  PRIMARY KEY(id)
+);
+
+CREATE INDEX ingestion_runs_started_at_idx ON ingestion_runs (started_at DESC);
+
+CREATE TABLE backfill_checkpoints (
+  name varchar(255),
+  day DATE NOT NULL,
+  key varchar(100) NOT NULL DEFAULT '',
+  key_values text[] NOT NULL DEFAULT '{}',
+  page int NOT NULL DEFAULT 1,
+  requests int NOT NULL DEFAULT 0,
+  done boolean NOT NULL DEFAULT false,
+  updated_at TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+  PRIMARY KEY(name)
+);
+
+CREATE TABLE client_keys (
+  id bigserial,
+  name varchar(255) NOT NULL,
+  prefix varchar(20) NOT NULL,
+  hash char(64) NOT NULL UNIQUE,
+  scopes text[] NOT NULL,
+  tier varchar(50) NOT NULL DEFAULT 'default',
+  created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+  revoked_at TIMESTAMP WITHOUT TIME ZONE,
+  PRIMARY KEY(id)
+);
+
+CREATE TABLE rate_limits (
+  bucket varchar(255),
+  window_start TIMESTAMP WITHOUT TIME ZONE,
+  count int NOT NULL DEFAULT 0,
+  expires_at TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+  PRIMARY KEY(bucket, window_start)
+);
+
+CREATE INDEX rate_limits_expires_at_idx ON rate_limits (expires_at);
+
+-- schema_migrations records the versions of this schema applied to the database,
+-- bump it along with store.SchemaVersion whenever the schema changes.
+CREATE TABLE schema_migrations (
+  version int,
+  applied_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY(version)
+);
+
+INSERT INTO schema_migrations (version) VALUES (1), (2);
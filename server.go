@@ -0,0 +1,64 @@
+package main
+
+// This file contains the HTTP server and its graceful shutdown.
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/riacataquian/news/internal/config"
+)
+
+// newServer returns an HTTP server of h as per conf.
+func newServer(conf config.Server, h http.Handler) *http.Server {
+	srv := &http.Server{
+		Addr:         conf.Addr,
+		Handler:      h,
+		ReadTimeout:  conf.ReadTimeout.Duration,
+		WriteTimeout: conf.WriteTimeout.Duration,
+		IdleTimeout:  conf.IdleTimeout.Duration,
+	}
+	if !conf.HTTP2 {
+		// A non-nil, empty TLSNextProto disables net/http's automatic HTTP/2.
+		srv.TLSNextProto = make(map[string]func(*http.Server, *tls.Conn, http.Handler))
+	}
+	return srv
+}
+
+// listenAndServe serves srv until SIGINT or SIGTERM is received, then shuts it down:
+// it stops accepting connections and waits up to conf.ShutdownTimeout for in-flight requests to finish.
+func listenAndServe(srv *http.Server, conf config.Server) error {
+	errc := make(chan error, 1)
+	go func() {
+		log.Printf("listening on %s", conf.Addr)
+		if conf.TLSCertFile != "" {
+			errc <- srv.ListenAndServeTLS(conf.TLSCertFile, conf.TLSKeyFile)
+			return
+		}
+		errc <- srv.ListenAndServe()
+	}()
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sig)
+
+	select {
+	case err := <-errc:
+		return fmt.Errorf("could not listen to %s: %v", conf.Addr, err)
+	case s := <-sig:
+		log.Printf("received %v, shutting down", s)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), conf.ShutdownTimeout.Duration)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		return fmt.Errorf("error shutting down: %v", err)
+	}
+	return nil
+}
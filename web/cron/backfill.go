@@ -0,0 +1,252 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/riacataquian/news/api/news"
+	"github.com/riacataquian/news/internal/app"
+	"github.com/riacataquian/news/internal/clock"
+	"github.com/riacataquian/news/internal/ingestion"
+	"github.com/riacataquian/news/internal/newsclient"
+)
+
+const (
+	// BackfillJob is the name of the job that fetches historical news as per the watchlist.
+	BackfillJob = "backfill"
+
+	// defaultBackfillBudget is the default count of requests a backfill run can make.
+	defaultBackfillBudget = 100
+	// backfillPageSize is the page size for backfill requests, newsapi's maximum.
+	backfillPageSize = 100
+
+	// isoFormat is newsapi's ISO date and time format, i.e., 2018-07-28T14:28:41.
+	isoFormat = "2006-01-02T15:04:05"
+	oneDay    = 24 * time.Hour
+)
+
+var (
+	// ErrInvalidBackfillRange is the error message if a backfill has no or an inverted date range.
+	ErrInvalidBackfillRange = errors.New("invalid backfill range, both from and to dates are required and from should not be after to")
+)
+
+// backfill fetches and persists news as per opts.Watchlist for the dates opts.From to opts.To.
+//
+// It walks the date range backwards in day-sized windows, from the newest day,
+// paging through the results of each watchlist entry per day.
+// Its progress is checkpointed after every page and every watchlist entry if a's data repository is an
+// ingestion.CheckpointStore, so that a backfill of the same date range resumes where it stopped, i.e., after a crash.
+//
+// It makes at most opts.Budget requests, then stops with ingestion.ErrBudgetExhausted.
+//
+// NOTE: The current newsapi plan only allows fetching news not older than 7 days from now.
+func backfill(ctx context.Context, a *app.App, rec *recorder, opts Options) ([]TopQueried, error) {
+	from, to := truncateDay(opts.From), truncateDay(opts.To)
+	if from.IsZero() || to.IsZero() || from.After(to) {
+		return nil, ErrInvalidBackfillRange
+	}
+
+	budget := opts.Budget
+	if budget <= 0 {
+		budget = defaultBackfillBudget
+	}
+
+	cps, _ := a.Store.(ingestion.CheckpointStore)
+	cp, err := loadCheckpoint(ctx, cps, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if cp.Done || len(opts.Watchlist) == 0 {
+		return nil, nil
+	}
+
+	// Resume from the checkpoint's watchlist entry and page, if it's still watched, from the day's first entry otherwise.
+	// Entries may share a key, i.e., several queries, so their values are matched too.
+	entry, page := 0, 1
+	for i, top := range opts.Watchlist {
+		if string(top.Key) == cp.Key && equal(top.Values, cp.Values) {
+			entry = i
+			if cp.Page > 1 {
+				page = cp.Page
+			}
+			break
+		}
+	}
+
+	// queried are the indices of the queried watchlist entries.
+	queried := make(map[int]bool)
+	requests := 0
+	for d := cp.Day; !d.Before(from); d = d.Add(-oneDay) {
+		for ; entry < len(opts.Watchlist); entry++ {
+			top := opts.Watchlist[entry]
+			params, ok := paramsFor(top, a.Config.Cron.Language)
+			if !ok {
+				log.Printf("unknown domain: %v", top.Key)
+				page = 1
+				continue
+			}
+			params.From = d.Format(isoFormat)
+			params.To = d.Add(oneDay - time.Second).Format(isoFormat)
+			params.PageSize = backfillPageSize
+
+			result := &ingestion.Result{
+				Key:    string(top.Key),
+				Values: top.Values,
+				From:   params.From,
+				To:     params.To,
+			}
+
+			// Pages past the plan's reachable results, i.e., of a checkpoint saved under a bigger plan, end the entry.
+			for pages := 0; reachable(page, backfillPageSize, a.Config.NewsAPI.MaxResults); pages++ {
+				if requests >= budget {
+					if pages > 0 {
+						rec.addResult(result)
+					}
+					return watched(opts.Watchlist, queried), saveCheckpoint(ctx, a.Clock, cps, cp, d, top, page, ingestion.ErrBudgetExhausted)
+				}
+
+				params.Page = page
+				res, persisted, err := fetchPage(ctx, a, params)
+				requests++
+				cp.Requests++
+				if res != nil {
+					result.Fetched += len(res.Articles)
+				}
+				result.Persisted += persisted
+				if err != nil {
+					result.Error = err.Error()
+					rec.addResult(result)
+					return nil, saveCheckpoint(ctx, a.Clock, cps, cp, d, top, page, err)
+				}
+
+				if len(res.Articles) == 0 || page*backfillPageSize >= res.TotalResults ||
+					!reachable(page+1, backfillPageSize, a.Config.NewsAPI.MaxResults) {
+					break
+				}
+				page++
+				if err := saveCheckpoint(ctx, a.Clock, cps, cp, d, top, page, nil); err != nil {
+					return nil, err
+				}
+			}
+
+			rec.addResult(result)
+			queried[entry] = true
+			page = 1
+
+			// Move the checkpoint to the day's next entry, if any, the previous day's first entry being saved below.
+			if entry+1 < len(opts.Watchlist) {
+				if err := saveCheckpoint(ctx, a.Clock, cps, cp, d, opts.Watchlist[entry+1], 1, nil); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		// Move the checkpoint to the previous day's first entry.
+		entry = 0
+		if err := saveCheckpoint(ctx, a.Clock, cps, cp, d.Add(-oneDay), opts.Watchlist[0], 1, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	cp.Done = true
+	return watched(opts.Watchlist, queried), saveCheckpoint(ctx, a.Clock, cps, cp, from, TopQueried{}, 1, nil)
+}
+
+// fetchPage fetches and persists a single page of results, timing out as configured, see fetchAndPersist.
+func fetchPage(ctx context.Context, a *app.App, params newsclient.Params) (*news.Response, int, error) {
+	reqCtx, cancel := context.WithTimeout(ctx, a.Config.NewsAPI.Timeout.Duration)
+	defer cancel()
+
+	return fetchAndPersist(reqCtx, a, params)
+}
+
+// reachable reports whether page is among the first maxResults results newsapi's plan lets us page through,
+// any page being reachable if maxResults is not positive.
+func reachable(page, pageSize, maxResults int) bool {
+	return maxResults <= 0 || (page-1)*pageSize < maxResults
+}
+
+// checkpointName identifies the backfill of the supplied date range.
+func checkpointName(from, to time.Time) string {
+	return fmt.Sprintf("%s:%s:%s", BackfillJob, from.Format("2006-01-02"), to.Format("2006-01-02"))
+}
+
+// loadCheckpoint returns the checkpoint of the backfill of the supplied date range,
+// or a new one starting from `to` if there's none.
+func loadCheckpoint(ctx context.Context, cps ingestion.CheckpointStore, from, to time.Time) (*ingestion.Checkpoint, error) {
+	fresh := &ingestion.Checkpoint{
+		Name: checkpointName(from, to),
+		Day:  to,
+		Page: 1,
+	}
+	if cps == nil {
+		return fresh, nil
+	}
+
+	cp, err := cps.GetCheckpoint(ctx, fresh.Name)
+	if err == ingestion.ErrCheckpointNotFound {
+		return fresh, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return cp, nil
+}
+
+// saveCheckpoint moves cp to the supplied position and persists it if possible.
+// It returns cause, or the error persisting cp if cause is nil.
+func saveCheckpoint(ctx context.Context, clk clock.Time, cps ingestion.CheckpointStore, cp *ingestion.Checkpoint, d time.Time, top TopQueried, page int, cause error) error {
+	cp.Day = d
+	cp.Key = string(top.Key)
+	cp.Values = top.Values
+	cp.Page = page
+	cp.UpdatedAt = clk.Now().UTC()
+	if cps == nil {
+		return cause
+	}
+
+	if err := cps.SaveCheckpoint(ctx, cp); err != nil {
+		if cause != nil {
+			log.Printf("error saving checkpoint %q: %v", cp.Name, err)
+			return cause
+		}
+		return err
+	}
+	return cause
+}
+
+// watched returns the entries of watchlist whose indices are present in queried, in order.
+func watched(watchlist []TopQueried, queried map[int]bool) []TopQueried {
+	var res []TopQueried
+	for i, top := range watchlist {
+		if queried[i] {
+			res = append(res, top)
+		}
+	}
+	return res
+}
+
+// equal reports whether a and b hold the same values in the same order.
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// truncateDay returns the start of t's day in UTC format.
+func truncateDay(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
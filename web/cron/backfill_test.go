@@ -0,0 +1,259 @@
+package cron
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+	"github.com/riacataquian/news/internal/app"
+	"github.com/riacataquian/news/internal/ingestion"
+)
+
+func TestBackfill(t *testing.T) {
+	t.Parallel()
+
+	fakes, teardown := setup(t, setupOpts{})
+	defer teardown()
+
+	repo := &fakecheckpointstore{}
+	from := time.Date(2018, time.July, 27, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2018, time.July, 28, 13, 0, 0, 0, time.UTC)
+	opts := Options{From: from, To: to, Budget: 3, Watchlist: testWatchlist}
+	name := checkpointName(from, to)
+
+	// 2 days of 2 watchlist entries of a single page each need 4 requests, more than the budget.
+	rec := newRecorder(context.Background(), fakes.with(repo), newRun(BackfillJob, ingestion.Backfill, fakes.clock.Now()))
+	_, err := backfill(context.Background(), fakes.with(repo), rec, opts)
+	if err != ingestion.ErrBudgetExhausted {
+		t.Fatalf("backfill(_, _, _, %v): want (_, ErrBudgetExhausted), got (_, %v)", opts, err)
+	}
+
+	cp := repo.checkpoints[name]
+	if !cp.Day.Equal(from) || cp.Key != string(sources) || cp.Page != 1 || cp.Requests != 3 || cp.Done {
+		t.Errorf("backfill(_, _, _, %v): want checkpoint at %v %s page 1 after 3 requests, got %+v", opts, from, sources, cp)
+	}
+
+	wantResults := []struct{ key, from string }{
+		{string(domains), "2018-07-28T00:00:00"},
+		{string(sources), "2018-07-28T00:00:00"},
+		{string(domains), "2018-07-27T00:00:00"},
+	}
+	if len(rec.run.Results) != len(wantResults) {
+		t.Fatalf("backfill(_, _, _, %v): want %d results, got %+v", opts, len(wantResults), rec.run.Results)
+	}
+	for i, want := range wantResults {
+		if got := rec.run.Results[i]; got.Key != want.key || got.From != want.from {
+			t.Errorf("backfill(_, _, _, %v): want result %d for %s from %s, got %+v", opts, i, want.key, want.from, got)
+		}
+	}
+
+	// Resuming only needs the last request.
+	rec = newRecorder(context.Background(), fakes.with(repo), newRun(BackfillJob, ingestion.Backfill, fakes.clock.Now()))
+	if _, err := backfill(context.Background(), fakes.with(repo), rec, opts); err != nil {
+		t.Fatalf("backfill(_, _, _, %v): want (_, nil) when resuming, got (_, %v)", opts, err)
+	}
+
+	cp = repo.checkpoints[name]
+	if !cp.Done || cp.Requests != 4 {
+		t.Errorf("backfill(_, _, _, %v): want a done checkpoint after 4 requests, got %+v", opts, cp)
+	}
+	if len(rec.run.Results) != 1 || rec.run.Results[0].Key != string(sources) {
+		t.Errorf("backfill(_, _, _, %v): want to resume with %s, got %+v", opts, sources, rec.run.Results)
+	}
+}
+
+func TestBackfillSharedKeys(t *testing.T) {
+	t.Parallel()
+
+	fakes, teardown := setup(t, setupOpts{})
+	defer teardown()
+
+	repo := &fakecheckpointstore{}
+	day := time.Date(2018, time.July, 28, 0, 0, 0, 0, time.UTC)
+	watchlist := []TopQueried{
+		{Key: query, Values: []string{"golang"}},
+		{Key: query, Values: []string{"bitcoin"}},
+		{Key: query, Values: []string{"rust"}},
+	}
+	opts := Options{From: day, To: day, Budget: 1, Watchlist: watchlist}
+
+	rec := newRecorder(context.Background(), fakes.with(repo), newRun(BackfillJob, ingestion.Backfill, fakes.clock.Now()))
+	if _, err := backfill(context.Background(), fakes.with(repo), rec, opts); err != ingestion.ErrBudgetExhausted {
+		t.Fatalf("backfill(_, _, _, %v): want (_, ErrBudgetExhausted), got (_, %v)", opts, err)
+	}
+
+	cp := repo.checkpoints[checkpointName(day, day)]
+	if cp.Key != string(query) || len(cp.Values) != 1 || cp.Values[0] != "bitcoin" {
+		t.Errorf("backfill(_, _, _, %v): want checkpoint at query bitcoin, got %+v", opts, cp)
+	}
+
+	// Resuming queries the entries following the first one, not only the last one sharing its key.
+	opts.Budget = 2
+	rec = newRecorder(context.Background(), fakes.with(repo), newRun(BackfillJob, ingestion.Backfill, fakes.clock.Now()))
+	if _, err := backfill(context.Background(), fakes.with(repo), rec, opts); err != nil {
+		t.Fatalf("backfill(_, _, _, %v): want (_, nil) when resuming, got (_, %v)", opts, err)
+	}
+
+	var got [][]string
+	for _, r := range rec.run.Results {
+		got = append(got, r.Values)
+	}
+	want := [][]string{{"bitcoin"}, {"rust"}}
+	if diff := pretty.Compare(got, want); diff != "" {
+		t.Errorf("backfill(_, _, _, %v) diff: (-got +want)\n%s", opts, diff)
+	}
+}
+
+func TestBackfillCheckpoints(t *testing.T) {
+	t.Parallel()
+
+	fakes, teardown := setup(t, setupOpts{})
+	defer teardown()
+
+	repo := &fakecheckpointstore{}
+	from := time.Date(2018, time.July, 27, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2018, time.July, 28, 0, 0, 0, 0, time.UTC)
+	opts := Options{From: from, To: to, Budget: 10, Watchlist: testWatchlist}
+
+	rec := newRecorder(context.Background(), fakes.with(repo), newRun(BackfillJob, ingestion.Backfill, fakes.clock.Now()))
+	if _, err := backfill(context.Background(), fakes.with(repo), rec, opts); err != nil {
+		t.Fatalf("backfill(_, _, _, %v): want (_, nil), got (_, %v)", opts, err)
+	}
+
+	// Each entry of a single page is checkpointed once it's queried.
+	type position struct {
+		Day      string
+		Key      string
+		Page     int
+		Requests int
+		Done     bool
+	}
+	var got []position
+	for _, cp := range repo.saved {
+		got = append(got, position{cp.Day.Format("2006-01-02"), cp.Key, cp.Page, cp.Requests, cp.Done})
+	}
+	want := []position{
+		{"2018-07-28", string(sources), 1, 1, false},
+		{"2018-07-27", string(domains), 1, 2, false},
+		{"2018-07-27", string(sources), 1, 3, false},
+		{"2018-07-26", string(domains), 1, 4, false},
+		{"2018-07-27", "", 1, 4, true},
+	}
+	if diff := pretty.Compare(got, want); diff != "" {
+		t.Errorf("backfill(_, _, _, %v) checkpoints diff: (-got +want)\n%s", opts, diff)
+	}
+}
+
+func TestBackfillUnwatchedCheckpoint(t *testing.T) {
+	t.Parallel()
+
+	fakes, teardown := setup(t, setupOpts{})
+	defer teardown()
+
+	var pages []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pages = append(pages, r.URL.Query().Get("page"))
+		json.NewEncoder(w).Encode(fakeResponse)
+	}))
+	defer server.Close()
+
+	conf := *fakes.conf
+	conf.NewsAPI.ListURL = server.URL
+	conf.NewsAPI.MaxResults = 0
+
+	day := time.Date(2018, time.July, 28, 0, 0, 0, 0, time.UTC)
+	opts := Options{From: day, To: day, Watchlist: testWatchlist}
+	name := checkpointName(day, day)
+
+	// The checkpoint's entry is no longer watched, its page is of no other entry.
+	repo := &fakecheckpointstore{checkpoints: map[string]ingestion.Checkpoint{
+		name: {Name: name, Day: day, Key: string(query), Values: []string{"unwatched"}, Page: 2},
+	}}
+	a := fakes.with(repo)
+	a.Config = &conf
+	a.List = app.New(&conf, repo, a.APIKeys).List
+
+	rec := newRecorder(context.Background(), a, newRun(BackfillJob, ingestion.Backfill, fakes.clock.Now()))
+	if _, err := backfill(context.Background(), a, rec, opts); err != nil {
+		t.Fatalf("backfill(_, _, _, %v): want (_, nil), got (_, %v)", opts, err)
+	}
+
+	if diff := pretty.Compare(pages, []string{"1", "1"}); diff != "" {
+		t.Errorf("backfill(_, _, _, %v) pages diff: (-got +want)\n%s", opts, diff)
+	}
+}
+
+func TestBackfillUnreachablePage(t *testing.T) {
+	t.Parallel()
+
+	fakes, teardown := setup(t, setupOpts{})
+	defer teardown()
+
+	day := time.Date(2018, time.July, 28, 0, 0, 0, 0, time.UTC)
+	opts := Options{From: day, To: day, Budget: 10, Watchlist: testWatchlist}
+	name := checkpointName(day, day)
+
+	// The checkpoint is past the first 100 results, the only ones reachable as configured.
+	repo := &fakecheckpointstore{checkpoints: map[string]ingestion.Checkpoint{
+		name: {Name: name, Day: day, Key: string(domains), Values: testWatchlist[0].Values, Page: 3},
+	}}
+
+	rec := newRecorder(context.Background(), fakes.with(repo), newRun(BackfillJob, ingestion.Backfill, fakes.clock.Now()))
+	if _, err := backfill(context.Background(), fakes.with(repo), rec, opts); err != nil {
+		t.Fatalf("backfill(_, _, _, %v): want (_, nil), got (_, %v)", opts, err)
+	}
+
+	cp := repo.checkpoints[name]
+	if !cp.Done || cp.Requests != 1 {
+		t.Errorf("backfill(_, _, _, %v): want a done checkpoint after the 1 request of %s, got %+v", opts, sources, cp)
+	}
+}
+
+func TestBackfillErrors(t *testing.T) {
+	t.Parallel()
+
+	from := time.Date(2018, time.July, 27, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2018, time.July, 28, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		desc          string
+		opts          Options
+		isServerError bool
+		wantErr       error
+	}{
+		{
+			desc:    "returns an error when from date is missing",
+			opts:    Options{To: to, Watchlist: testWatchlist},
+			wantErr: ErrInvalidBackfillRange,
+		},
+		{
+			desc:    "returns an error when from date is after to date",
+			opts:    Options{From: to, To: from, Watchlist: testWatchlist},
+			wantErr: ErrInvalidBackfillRange,
+		},
+		{
+			desc:          "returns an error when server errored",
+			opts:          Options{From: from, To: to, Watchlist: testWatchlist},
+			isServerError: true,
+		},
+	}
+
+	for _, test := range tests {
+		fakes, teardown := setup(t, setupOpts{isServerError: test.isServerError})
+		defer teardown()
+
+		repo := &fakecheckpointstore{}
+		rec := newRecorder(context.Background(), fakes.with(repo), newRun(BackfillJob, ingestion.Backfill, fakes.clock.Now()))
+		_, err := backfill(context.Background(), fakes.with(repo), rec, test.opts)
+		if err == nil {
+			t.Fatalf("%s: backfill(_, _, _, %v): want (_, error), got (_, nil)", test.desc, test.opts)
+		}
+		if test.wantErr != nil && err != test.wantErr {
+			t.Errorf("%s: backfill(_, _, _, %v): want (_, %v), got (_, %v)", test.desc, test.opts, test.wantErr, err)
+		}
+	}
+}
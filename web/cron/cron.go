@@ -17,6 +17,8 @@ type TopQueried struct {
 
 // Log is the response for querying top queried news.
 type Log struct {
+	// RunID is the ID of the recorded ingestion.Run, if any.
+	RunID       int64
 	ElapsedTime time.Duration
 	Queried     []TopQueried
 }
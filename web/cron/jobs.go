@@ -0,0 +1,204 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/riacataquian/news/internal/app"
+	"github.com/riacataquian/news/internal/config"
+	"github.com/riacataquian/news/internal/ingestion"
+	"github.com/riacataquian/news/internal/logging"
+	"github.com/riacataquian/news/internal/trace"
+)
+
+var (
+	// ErrUnknownJob is the error message if no job matches the supplied name.
+	ErrUnknownJob = errors.New("unknown cron job")
+
+	// ErrNotInWatchlist is the error message if a run is restricted to entries that are not watched.
+	ErrNotInWatchlist = errors.New("not in the watchlist")
+
+	// ErrRunsNotRecorded is the error message if a job is started in the background
+	// but the data repository can't record its run for polling.
+	ErrRunsNotRecorded = errors.New("ingestion runs are not recorded by the data repository")
+)
+
+// started are the jobs running in the background, see Start and Wait.
+var started sync.WaitGroup
+
+// job describes a function that fetches and persists news as per the supplied options.
+// It records each query to the supplied recorder and returns the queried watchlist entries.
+type job func(context.Context, *app.App, *recorder, Options) ([]TopQueried, error)
+
+// jobs is the lookup table for job names and their matching functions.
+var jobs = map[string]job{
+	ListJob:     fetchWatchlist,
+	BackfillJob: backfill,
+}
+
+// Options configures a single execution of a job.
+type Options struct {
+	// Trigger is what started the run, defaults to ingestion.Manual.
+	Trigger ingestion.Trigger
+	// Watchlist restricts the run to these entries of the configured watchlist, if any.
+	Watchlist []TopQueried
+
+	// From and To are the dates, inclusive, to backfill news for.
+	From, To time.Time
+	// Budget is the maximum count of requests a backfill run can make.
+	Budget int
+}
+
+// Run executes the job matching name and waits for it to finish.
+//
+// It returns the run, recorded if a's data repository is an ingestion.Store.
+// The run is returned along with the job's error if the job failed.
+func Run(ctx context.Context, a *app.App, name string, opts Options) (*ingestion.Run, error) {
+	fn, opts, err := lookup(a.Config, name, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	rec := newRecorder(ctx, a, newRun(name, trigger(opts), a.Clock.Now()))
+	_, err = fn(ctx, a, rec, opts)
+	rec.finish(err)
+	return rec.run, err
+}
+
+// Start records a new run of the job matching name then executes it in the background.
+//
+// It returns the run as it started, without waiting for the job to finish.
+// Use the run's ID to poll the ingestion.Store for its progress.
+// The job is cancelled along with ctx but carries none of its values but its Tracer, see detach,
+// its spans being of a trace of their own. Its run is recorded as finished even if ctx is done.
+// Use Wait to wait for the started jobs to finish, i.e., before closing the data repository.
+func Start(ctx context.Context, a *app.App, name string, opts Options) (*ingestion.Run, error) {
+	fn, opts, err := lookup(a.Config, name, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	runs, ok := a.Store.(ingestion.Store)
+	if !ok {
+		return nil, ErrRunsNotRecorded
+	}
+
+	run := newRun(name, trigger(opts), a.Clock.Now())
+	if err := runs.CreateRun(ctx, run); err != nil {
+		return nil, err
+	}
+	created := *run
+
+	ctx = logging.NewContext(detach(ctx), logging.Default.With("runId", run.ID))
+	rec := &recorder{ctx: context.WithoutCancel(ctx), runs: runs, run: run, clock: a.Clock, started: a.Clock.Now()}
+	started.Add(1)
+	go func() {
+		defer started.Done()
+		ctx, span := trace.Start(ctx, "cron."+name)
+		defer span.End()
+		span.SetAttribute("ingestion.run_id", run.ID)
+
+		_, err := fn(ctx, a, rec, opts)
+		span.RecordError(err)
+		rec.finish(err)
+	}()
+
+	return &created, nil
+}
+
+// Wait waits for the jobs started in the background, see Start, to finish.
+func Wait() {
+	started.Wait()
+}
+
+// detached is a context cancelled along with its parent, carrying the values of values only.
+type detached struct {
+	context.Context
+	values context.Context
+}
+
+// Value implements context.Context.
+func (d detached) Value(key interface{}) interface{} {
+	return d.values.Value(key)
+}
+
+// detach returns a context cancelled along with ctx, carrying its Tracer but none of its other values,
+// i.e., the span, the logger and the client key of the request that started a job outliving it.
+func detach(ctx context.Context) context.Context {
+	values := context.Background()
+	if t, ok := trace.TracerFromContext(ctx); ok {
+		values = trace.NewContext(values, t)
+	}
+	return detached{Context: ctx, values: values}
+}
+
+// lookup returns the job matching name and opts with the entries of the conf's watchlist it should query.
+func lookup(conf *config.Config, name string, opts Options) (job, Options, error) {
+	fn, ok := jobs[name]
+	if !ok {
+		return nil, opts, ErrUnknownJob
+	}
+
+	w, err := restrict(watchlist(conf), opts.Watchlist)
+	if err != nil {
+		return nil, opts, err
+	}
+	opts.Watchlist = w
+	return fn, opts, nil
+}
+
+func trigger(opts Options) ingestion.Trigger {
+	if opts.Trigger == "" {
+		return ingestion.Manual
+	}
+	return opts.Trigger
+}
+
+// restrict returns the entries of watchlist restricted to the keys and values of override.
+//
+// It returns watchlist as is if override is empty, or an ErrNotInWatchlist if override
+// contains a key or a value that is not in watchlist.
+func restrict(watchlist, override []TopQueried) ([]TopQueried, error) {
+	if len(override) == 0 {
+		return watchlist, nil
+	}
+
+	var restricted []TopQueried
+	for _, o := range override {
+		var found *TopQueried
+		for i := range watchlist {
+			if watchlist[i].Key == o.Key {
+				found = &watchlist[i]
+				break
+			}
+		}
+		if found == nil {
+			return nil, fmt.Errorf("key %q: %w", o.Key, ErrNotInWatchlist)
+		}
+
+		if len(o.Values) == 0 {
+			restricted = append(restricted, *found)
+			continue
+		}
+
+		for _, v := range o.Values {
+			if !contains(found.Values, v) {
+				return nil, fmt.Errorf("%s %q: %w", o.Key, v, ErrNotInWatchlist)
+			}
+		}
+		restricted = append(restricted, TopQueried{Key: o.Key, Values: o.Values})
+	}
+	return restricted, nil
+}
+
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
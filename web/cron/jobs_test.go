@@ -0,0 +1,213 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+	"github.com/riacataquian/news/internal/ingestion"
+	"github.com/riacataquian/news/internal/logging"
+	"github.com/riacataquian/news/internal/trace"
+)
+
+var testWatchlist = []TopQueried{
+	{
+		Key:    domains,
+		Values: []string{"techcrunch.com", "wsj.com"},
+	},
+	{
+		Key:    sources,
+		Values: []string{"bloomberg"},
+	},
+}
+
+func TestRestrict(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc     string
+		override []TopQueried
+		want     []TopQueried
+	}{
+		{
+			desc: "returns the watchlist when there's no override",
+			want: testWatchlist,
+		},
+		{
+			desc:     "restricts to the supplied keys",
+			override: []TopQueried{{Key: sources}},
+			want:     testWatchlist[1:],
+		},
+		{
+			desc:     "restricts to the supplied values",
+			override: []TopQueried{{Key: domains, Values: []string{"wsj.com"}}},
+			want:     []TopQueried{{Key: domains, Values: []string{"wsj.com"}}},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := restrict(testWatchlist, test.override)
+		if err != nil {
+			t.Fatalf("%s: restrict(_, %v): want (_, nil), got (_, %v)", test.desc, test.override, err)
+		}
+
+		if diff := pretty.Compare(got, test.want); diff != "" {
+			t.Errorf("%s: restrict(_, %v) diff: (-got +want)\n%s", test.desc, test.override, diff)
+		}
+	}
+}
+
+func TestRestrictErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc     string
+		override []TopQueried
+	}{
+		{
+			desc:     "returns an error when key is not watched",
+			override: []TopQueried{{Key: query}},
+		},
+		{
+			desc:     "returns an error when value is not watched",
+			override: []TopQueried{{Key: domains, Values: []string{"nytimes.com"}}},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := restrict(testWatchlist, test.override)
+		if !errors.Is(err, ErrNotInWatchlist) {
+			t.Errorf("%s: restrict(_, %v): want (nil, ErrNotInWatchlist), got (%v, %v)", test.desc, test.override, got, err)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	fakes, teardown := setup(t, setupOpts{})
+	fakes.conf.Cron.Watchlist = watches(testWatchlist)
+	defer teardown()
+
+	repo := &fakerunstore{}
+	opts := Options{Watchlist: []TopQueried{{Key: sources}}}
+	got, err := Run(context.Background(), fakes.with(repo), ListJob, opts)
+	if err != nil {
+		t.Fatalf("Run(_, _, %q, %v): want (_, nil), got (%v, %v)", ListJob, opts, got, err)
+	}
+
+	if got.Trigger != ingestion.Manual || got.Status != ingestion.Succeeded {
+		t.Errorf("Run(_, _, %q, %v): want a succeeded manual run, got %+v", ListJob, opts, got)
+	}
+	if len(got.Results) != 1 || got.Results[0].Key != string(sources) {
+		t.Errorf("Run(_, _, %q, %v): want only sources to be queried, got %+v", ListJob, opts, got.Results)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	t.Parallel()
+
+	fakes, teardown := setup(t, setupOpts{})
+	fakes.conf.Cron.Watchlist = watches(testWatchlist)
+	defer teardown()
+
+	if got, err := Run(context.Background(), fakes.with(&fakerunstore{}), "unknown-job", Options{}); err != ErrUnknownJob {
+		t.Errorf("Run(_, _, %q, _): want (nil, ErrUnknownJob), got (%v, %v)", "unknown-job", got, err)
+	}
+}
+
+func TestStart(t *testing.T) {
+	t.Parallel()
+
+	fakes, teardown := setup(t, setupOpts{})
+	fakes.conf.Cron.Watchlist = watches(testWatchlist)
+	defer teardown()
+
+	repo := &fakesyncrunstore{finished: make(chan ingestion.Run, 1)}
+	got, err := Start(context.Background(), fakes.with(repo), ListJob, Options{})
+	if err != nil {
+		t.Fatalf("Start(_, _, %q, _): want (_, nil), got (%v, %v)", ListJob, got, err)
+	}
+
+	if got.ID == 0 || got.Status != ingestion.Running {
+		t.Errorf("Start(_, _, %q, _): want a recorded running run, got %+v", ListJob, got)
+	}
+
+	select {
+	case run := <-repo.finished:
+		if run.ID != got.ID || run.Status != ingestion.Succeeded {
+			t.Errorf("Start(_, _, %q, _): want run %d to succeed, got %+v", ListJob, got.ID, run)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Start(_, _, %q, _): run %d did not finish", ListJob, got.ID)
+	}
+}
+
+func TestStartCancelled(t *testing.T) {
+	t.Parallel()
+
+	fakes, teardown := setup(t, setupOpts{})
+	fakes.conf.Cron.Watchlist = watches(testWatchlist)
+	defer teardown()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	repo := &fakesyncrunstore{finished: make(chan ingestion.Run, 1)}
+	got, err := Start(ctx, fakes.with(repo), ListJob, Options{})
+	if err != nil {
+		t.Fatalf("Start(_, _, %q, _): want (_, nil), got (%v, %v)", ListJob, got, err)
+	}
+	cancel()
+	Wait()
+
+	select {
+	case run := <-repo.finished:
+		if run.ID != got.ID {
+			t.Errorf("Start(_, _, %q, _): want run %d to be recorded as finished, got %+v", ListJob, got.ID, run)
+		}
+	default:
+		t.Errorf("Start(_, _, %q, _): want run %d to be recorded as finished once cancelled, got none", ListJob, got.ID)
+	}
+}
+
+func TestStartErrors(t *testing.T) {
+	t.Parallel()
+
+	fakes, teardown := setup(t, setupOpts{})
+	fakes.conf.Cron.Watchlist = watches(testWatchlist)
+	defer teardown()
+
+	if got, err := Start(context.Background(), fakes.with(&fakestore{}), ListJob, Options{}); err != ErrRunsNotRecorded {
+		t.Errorf("Start(_, _, %q, _): want (nil, ErrRunsNotRecorded), got (%v, %v)", ListJob, got, err)
+	}
+}
+
+func TestDetach(t *testing.T) {
+	t.Parallel()
+
+	tracer := trace.NewTracer(nil)
+	parent, cancel := context.WithCancel(trace.NewContext(context.Background(), tracer))
+	parent = logging.NewContext(parent, logging.New(ioutil.Discard))
+	parent, span := trace.Start(parent, "GET /admin/cron/{job}/run")
+	defer span.End()
+
+	ctx := detach(parent)
+	if got, ok := trace.TracerFromContext(ctx); !ok || got != tracer {
+		t.Errorf("detach(_): want the parent's Tracer, got (%v, %v)", got, ok)
+	}
+	if got, ok := trace.FromContext(ctx); ok {
+		t.Errorf("detach(_): want no span, got %v", got)
+	}
+	if got := logging.FromContext(ctx); got != logging.Default {
+		t.Errorf("detach(_): want the Default logger, got %v", got)
+	}
+
+	cancel()
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Errorf("detach(_): want to be cancelled along with the parent")
+	}
+}
@@ -6,15 +6,14 @@ import (
 	"log"
 	"net/http"
 	"strings"
-	"time"
 
 	"github.com/riacataquian/news/api/news"
-	"github.com/riacataquian/news/internal/auth"
-	"github.com/riacataquian/news/internal/clock"
+	"github.com/riacataquian/news/internal/app"
+	"github.com/riacataquian/news/internal/config"
+	"github.com/riacataquian/news/internal/ingestion"
 	"github.com/riacataquian/news/internal/newsclient"
 	"github.com/riacataquian/news/internal/newsclient/list"
 	"github.com/riacataquian/news/internal/persistence"
-	"github.com/riacataquian/news/internal/store"
 )
 
 const (
@@ -22,129 +21,137 @@ const (
 	sources Key = "sources"
 	query   Key = "query"
 
-	defaultLang = "en"
+	// ListJob is the name of the job that fetches news as per the watchlist.
+	ListJob = "list"
 )
 
-var (
-	client newsclient.HTTPClient
-
-	timer        = clock.New()
-	listEndpoint = list.ServiceEndpoint
-)
-
-// topQueried are hard-coded values that represents the top queried news given a domain.
-// TODO: Replace me with actual values, retrieve from, ideally, a data repository.
-var topQueried = []TopQueried{
-	{
-		Key:    domains,
-		Values: []string{"techcrunch.com", "nytimes.com", "wsj.com"},
-	},
-	{
-		Key:    sources,
-		Values: []string{"bloomberg", "financial-times", "the-wall-street-journal"},
-	},
-	{
-		Key:    query,
-		Values: []string{"bitcoin", "ethereum", "blockchain"},
-	},
+// watchlist returns the top queried values of conf, per request parameter key.
+func watchlist(conf *config.Config) []TopQueried {
+	var w []TopQueried
+	for _, watch := range conf.Cron.Watchlist {
+		w = append(w, TopQueried{Key: Key(watch.Key), Values: watch.Values})
+	}
+	return w
 }
 
-// List fetches news as per topQueried values.
+// List fetches news as per the configured watchlist of a.
 //
 // It connects to https://newsapi.org to fetch the first 20 news
-// that matches the values defined in topQueried, per key,
+// that matches the values defined in the watchlist, per key,
 // and persist the results to the datastore.
 // Finally, it returns the log containing the query parameters and the elapsed time
 // performing the transactions.
 //
-// By default, language is set to "en".
+// Each call is recorded as an ingestion.Run if a's data repository is an ingestion.Store.
+//
+// News are fetched in the configured language, "en" by default.
 // See https://newsapi.org/docs/endpoints/everything > Request Parameters
 // on how to construct params.
 //
 // NOTE:
 // Current newsapi plan fetch news anything not older than 7days from now.
-// Future plans includes fetch all data which are 7 days old.
-func List(ctx context.Context, repo store.Store, r *http.Request) (*Log, error) {
-	// Requests to external services should timeout for 5 seconds.
-	reqCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
-
-	client = newsclient.New(listEndpoint)
-	started := timer.Now()
+// Use the backfill job to fetch older news, see cmd/backfill.
+func List(ctx context.Context, a *app.App, r *http.Request) (*Log, error) {
+	rec := newRecorder(ctx, a, newRun(ListJob, ingestion.Scheduled, a.Clock.Now()))
 
-	var queried []TopQueried
-	for _, top := range topQueried {
-		switch domain := top.Key; domain {
-		case domains:
-			params := &list.Params{
-				Language: defaultLang,
-				Domains:  strings.Join(top.Values, ","),
-			}
-			_, err := fetchAndPersist(reqCtx, repo, client, params)
-			if err != nil {
-				return nil, err
-			}
-			queried = append(queried, top)
-		case sources:
-			params := &list.Params{
-				Language: defaultLang,
-				Sources:  strings.Join(top.Values, ","),
-			}
-			_, err := fetchAndPersist(reqCtx, repo, client, params)
-			if err != nil {
-				return nil, err
-			}
-			queried = append(queried, top)
-		case query:
-			// Surround phrases with quotes for exact match.
-			var q []string
-			for _, term := range top.Values {
-				q = append(q, fmt.Sprintf("%q", term))
-			}
-
-			params := &list.Params{
-				Language: defaultLang,
-				Query:    strings.Join(q, "+"),
-			}
-			_, err := fetchAndPersist(reqCtx, repo, client, params)
-			if err != nil {
-				return nil, err
-			}
-			queried = append(queried, top)
-		default:
-			log.Printf("unknown domain: %v", domain)
-		}
+	queried, err := fetchWatchlist(ctx, a, rec, Options{Watchlist: watchlist(a.Config)})
+	rec.finish(err)
+	if err != nil {
+		return nil, err
 	}
 
 	return &Log{
+		RunID:       rec.run.ID,
 		Queried:     queried,
-		ElapsedTime: timer.Since(started),
+		ElapsedTime: a.Clock.Since(rec.started),
 	}, nil
 }
 
-// fetchAndPersist connects to newsapi via a newsclient
-// then persists the results to the supplied repo.
-func fetchAndPersist(ctx context.Context, repo store.Store, client newsclient.HTTPClient, params newsclient.Params) (*news.Response, error) {
-	authKey, err := auth.LookupAPIAuthKey()
-	if err != nil {
-		return nil, err
+// fetchWatchlist fetches and persists news as per opts.Watchlist, recording each query to rec.
+// It returns the queried entries of the watchlist.
+func fetchWatchlist(ctx context.Context, a *app.App, rec *recorder, opts Options) ([]TopQueried, error) {
+	// Requests to external services should have timeouts.
+	reqCtx, cancel := context.WithTimeout(ctx, a.Config.NewsAPI.Timeout.Duration)
+	defer cancel()
+
+	var queried []TopQueried
+	for _, top := range opts.Watchlist {
+		params, ok := paramsFor(top, a.Config.Cron.Language)
+		if !ok {
+			log.Printf("unknown domain: %v", top.Key)
+			continue
+		}
+
+		res, persisted, err := fetchAndPersist(reqCtx, a, params)
+		rec.add(top, res, persisted, err)
+		if err != nil {
+			return nil, err
+		}
+		queried = append(queried, top)
 	}
 
-	res, err := client.Get(ctx, authKey, params)
+	return queried, nil
+}
+
+// paramsFor returns the list.Params to query the supplied watchlist entry in lang.
+// It returns false if the entry's key is unknown.
+func paramsFor(top TopQueried, lang string) (*list.Params, bool) {
+	switch top.Key {
+	case domains:
+		return &list.Params{
+			Language: lang,
+			Domains:  strings.Join(top.Values, ","),
+		}, true
+	case sources:
+		return &list.Params{
+			Language: lang,
+			Sources:  strings.Join(top.Values, ","),
+		}, true
+	case query:
+		// Surround phrases with quotes for exact match.
+		var q []string
+		for _, term := range top.Values {
+			q = append(q, fmt.Sprintf("%q", term))
+		}
+
+		return &list.Params{
+			Language: lang,
+			Query:    strings.Join(q, "+"),
+		}, true
+	default:
+		return nil, false
+	}
+}
+
+// fetchAndPersist connects to newsapi's everything endpoint via a's client, authenticated with a key
+// from a's pool, then persists the results to a's data repository.
+//
+// It returns the response along with the count of its articles persisted, those persisted
+// before a failure included.
+func fetchAndPersist(ctx context.Context, a *app.App, params newsclient.Params) (*news.Response, int, error) {
+	var res *news.Response
+	err := a.APIKeys.Do(func(authKey string) error {
+		var err error
+		res, err = a.List.Get(ctx, authKey, params)
+		return err
+	})
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	for _, row := range res.Articles {
-		err := persistence.ScanRow(row).Create(repo, timer)
+	if len(res.Articles) == 0 {
+		return &news.Response{
+			Status:       res.Status,
+			TotalResults: 0,
+		}, 0, nil
+	}
+
+	for i, row := range res.Articles {
+		err := persistence.ScanRow(row).Create(ctx, a.Store, a.Clock)
 		if err != nil {
-			return nil, err
+			return res, i, err
 		}
-		return res, nil
 	}
 
-	return &news.Response{
-		Status:       res.Status,
-		TotalResults: 0,
-	}, nil
+	return res, len(res.Articles), nil
 }
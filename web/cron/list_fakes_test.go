@@ -6,11 +6,17 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
-	"os"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/riacataquian/news/api/news"
+	"github.com/riacataquian/news/internal/app"
+	"github.com/riacataquian/news/internal/auth"
+	"github.com/riacataquian/news/internal/clock"
+	"github.com/riacataquian/news/internal/config"
+	"github.com/riacataquian/news/internal/ingestion"
+	"github.com/riacataquian/news/internal/lock"
 	"github.com/riacataquian/news/internal/newsclient"
 	"github.com/riacataquian/news/internal/store"
 )
@@ -73,13 +79,15 @@ func (f *fakeclient) Get(ctx context.Context, authKey string, p newsclient.Param
 
 type fakestore struct {
 	isError bool
+	// maxRows, if positive, is the count of rows inserted before Create errors.
+	maxRows int
 	// rows are the supposedly inserted rows.
 	// rows is set after calling fakestore's Create method.
 	rows []store.Row
 }
 
-func (f *fakestore) Create(table string, cols []string, rows ...store.Row) error {
-	if f.isError {
+func (f *fakestore) Create(_ context.Context, table string, cols []string, rows ...store.Row) error {
+	if f.isError || f.maxRows > 0 && len(f.rows)+len(rows) > f.maxRows {
 		return errors.New("some store error")
 	}
 
@@ -87,15 +95,137 @@ func (f *fakestore) Create(table string, cols []string, rows ...store.Row) error
 	return nil
 }
 
+// fakerunstore is a fakestore that also records ingestion runs.
+type fakerunstore struct {
+	fakestore
+	// runs are the supposedly persisted runs, keyed by ID.
+	runs map[int64]ingestion.Run
+}
+
+func (f *fakerunstore) CreateRun(_ context.Context, run *ingestion.Run) error {
+	if f.runs == nil {
+		f.runs = make(map[int64]ingestion.Run)
+	}
+	run.ID = int64(len(f.runs) + 1)
+	f.runs[run.ID] = *run
+	return nil
+}
+
+func (f *fakerunstore) UpdateRun(_ context.Context, run *ingestion.Run) error {
+	f.runs[run.ID] = *run
+	return nil
+}
+
+func (f *fakerunstore) ListRuns(_ context.Context, _, _ int) ([]*ingestion.Run, int, error) {
+	return nil, 0, errors.New("not implemented")
+}
+
+func (f *fakerunstore) GetRun(_ context.Context, id int64) (*ingestion.Run, error) {
+	run, ok := f.runs[id]
+	if !ok {
+		return nil, ingestion.ErrRunNotFound
+	}
+	return &run, nil
+}
+
+func (f *fakerunstore) LatestRun(_ context.Context, filter ingestion.Filter) (*ingestion.Run, error) {
+	var latest *ingestion.Run
+	for id := range f.runs {
+		run := f.runs[id]
+		if filter.Job != "" && run.Job != filter.Job ||
+			filter.Trigger != "" && run.Trigger != filter.Trigger ||
+			filter.Status != "" && run.Status != filter.Status {
+			continue
+		}
+		if latest == nil || run.StartedAt.After(latest.StartedAt) || run.StartedAt.Equal(latest.StartedAt) && run.ID > latest.ID {
+			latest = &run
+		}
+	}
+	if latest == nil {
+		return nil, ingestion.ErrRunNotFound
+	}
+	return latest, nil
+}
+
+// fakecheckpointstore is a fakestore that also saves checkpoints.
+type fakecheckpointstore struct {
+	fakestore
+	// checkpoints are the supposedly saved checkpoints, keyed by name.
+	checkpoints map[string]ingestion.Checkpoint
+	// saved are the checkpoints in the order they were saved.
+	saved []ingestion.Checkpoint
+}
+
+func (f *fakecheckpointstore) GetCheckpoint(_ context.Context, name string) (*ingestion.Checkpoint, error) {
+	cp, ok := f.checkpoints[name]
+	if !ok {
+		return nil, ingestion.ErrCheckpointNotFound
+	}
+	return &cp, nil
+}
+
+func (f *fakecheckpointstore) SaveCheckpoint(_ context.Context, cp *ingestion.Checkpoint) error {
+	if f.checkpoints == nil {
+		f.checkpoints = make(map[string]ingestion.Checkpoint)
+	}
+	f.checkpoints[cp.Name] = *cp
+	f.saved = append(f.saved, *cp)
+	return nil
+}
+
+// fakesyncrunstore is a fakerunstore safe for jobs running in the background.
+// It sends runs to finished once they're no longer running.
+type fakesyncrunstore struct {
+	mu sync.Mutex
+	fakerunstore
+	finished chan ingestion.Run
+}
+
+func (f *fakesyncrunstore) CreateRun(ctx context.Context, run *ingestion.Run) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.fakerunstore.CreateRun(ctx, run)
+}
+
+func (f *fakesyncrunstore) UpdateRun(ctx context.Context, run *ingestion.Run) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if run.Status != ingestion.Running {
+		f.finished <- *run
+	}
+	return f.fakerunstore.UpdateRun(ctx, run)
+}
+
+// fakelocker is a lock.Locker whose locks record the error of the context they're released with.
+type fakelocker struct {
+	unlocked  bool
+	unlockErr error
+}
+
+func (f *fakelocker) TryLock(_ context.Context, _ string) (lock.Lock, bool, error) {
+	return f, true, nil
+}
+
+func (f *fakelocker) Unlock(ctx context.Context) error {
+	f.unlocked = true
+	f.unlockErr = ctx.Err()
+	return f.unlockErr
+}
+
+// fakeclock is a fake clock set to 2016-08-15 plus nsec nanoseconds,
+// whose elapsed time is always 123.
 type fakeclock struct {
-	nsec int
+	*clock.Fake
 }
 
-func (c fakeclock) Now() time.Time {
-	return time.Date(2016, time.August, 15, 0, 0, 0, c.nsec, time.UTC)
+func newFakeclock(nsec int) *fakeclock {
+	return &fakeclock{Fake: clock.NewFake(time.Date(2016, time.August, 15, 0, 0, 0, nsec, time.UTC))}
 }
 
-func (c fakeclock) Since(_ time.Time) time.Duration {
+func (c *fakeclock) Since(_ time.Time) time.Duration {
 	return 123
 }
 
@@ -130,8 +260,8 @@ type serviceEndpoint struct {
 	RequestURL string
 }
 
-// config encapsulates a test's setup configuration.
-type config struct {
+// setupOpts encapsulates a test's setup configuration.
+type setupOpts struct {
 	isServerError   bool
 	isStoreError    bool
 	isAPIKeyMissing bool
@@ -140,41 +270,62 @@ type config struct {
 
 // fakes encapsulates a test's fake structures.
 type fakes struct {
+	// app is built from the other fakes, conf is its configuration.
+	app    *app.App
+	conf   *config.Config
 	server *httptest.Server
 	store  *fakestore
 	clock  *fakeclock
 }
 
+// with returns a copy of the fake app storing data in repo.
+func (f *fakes) with(repo store.Store) *app.App {
+	a := *f.app
+	a.Store = repo
+	return &a
+}
+
 type teardown func()
 
-// setup performs the necessary monkey-patching per test suite
-// then return a teardown function to return back original values of package wide vars.
-func setup(t *testing.T, conf config) (*fakes, teardown) {
+// watches returns the watchlist entries of a config.Config for tops.
+func watches(tops []TopQueried) []config.Watch {
+	var w []config.Watch
+	for _, top := range tops {
+		w = append(w, config.Watch{Key: string(top.Key), Values: top.Values})
+	}
+	return w
+}
+
+// setup builds an app out of fakes per test
+// then return a teardown function to stop the fake server.
+func setup(t *testing.T, opts setupOpts) (*fakes, teardown) {
 	t.Helper()
 
-	if !conf.isAPIKeyMissing {
-		os.Setenv("API_KEY", "test-api-key")
+	var keys []string
+	if !opts.isAPIKeyMissing {
+		keys = []string{"test-api-key"}
 	}
 
-	fakeserver := setupStubServer(t, conf.isServerError)
-	fakestore := &fakestore{isError: conf.isStoreError}
-	fakeclock := &fakeclock{nsec: conf.clockNanosec}
+	fakeserver := setupStubServer(t, opts.isServerError)
+	fakestore := &fakestore{isError: opts.isStoreError}
+	fakeclock := newFakeclock(opts.clockNanosec)
+
+	conf := config.Default()
+	conf.NewsAPI.ListURL = fakeserver.URL
 
-	timer = fakeclock
+	a := app.New(conf, fakestore, auth.NewKeyPool(keys, auth.DefaultCooldown))
+	a.Clock = fakeclock
 
 	fakes := fakes{
+		app:    a,
+		conf:   conf,
 		server: fakeserver,
 		store:  fakestore,
 		clock:  fakeclock,
 	}
 
 	teardown := func() {
-		os.Clearenv()
 		fakeserver.Close()
-
-		client = originalClient
-		timer = originalTimer
-		topQueried = originalTopQueried
 	}
 
 	return &fakes, teardown
@@ -8,18 +8,15 @@ import (
 
 	"github.com/kylelemons/godebug/pretty"
 	"github.com/riacataquian/news/api/news"
-	"github.com/riacataquian/news/internal/newsclient"
+	"github.com/riacataquian/news/internal/config"
+	"github.com/riacataquian/news/internal/ingestion"
 	"github.com/riacataquian/news/internal/newsclient/list"
 	"github.com/riacataquian/news/internal/store"
 )
 
-var (
-	originalClient     = client
-	originalTimer      = timer
-	originalTopQueried = topQueried
-)
-
 func TestList(t *testing.T) {
+	t.Parallel()
+
 	tests := []struct {
 		desc          string
 		isServerValid bool
@@ -27,10 +24,10 @@ func TestList(t *testing.T) {
 		wantLog       *Log
 	}{
 		{
-			desc:          "returns the elapsed time after fetching topQueried items",
+			desc:          "returns the elapsed time after fetching the watchlist",
 			isServerValid: true,
 			wantLog: &Log{
-				Queried:     topQueried,
+				Queried:     watchlist(config.Default()),
 				ElapsedTime: 123,
 			},
 		},
@@ -60,18 +57,14 @@ func TestList(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		fakes, teardown := setup(t, config{})
-		listEndpoint = newsclient.ServiceEndpoint{
-			RequestURL: fakes.server.URL,
-			DocsURL:    "http://fake-docs-url",
-		}
+		fakes, teardown := setup(t, setupOpts{})
 		if len(test.topQueried) > 0 {
-			topQueried = test.topQueried
+			fakes.conf.Cron.Watchlist = watches(test.topQueried)
 		}
 		defer teardown()
 
 		r := httptest.NewRequest("GET", "/test", nil)
-		got, err := List(context.Background(), fakes.store, r)
+		got, err := List(context.Background(), fakes.app, r)
 		if err != nil {
 			t.Errorf("%s: List(_, _, _): want (_, nil), got (_, %v)", test.desc, err)
 		}
@@ -85,14 +78,12 @@ func TestList(t *testing.T) {
 }
 
 func TestListErrors(t *testing.T) {
-	fakes, teardown := setup(t, config{
+	t.Parallel()
+
+	fakes, teardown := setup(t, setupOpts{
 		isAPIKeyMissing: true,
 		clockNanosec:    123,
 	})
-	listEndpoint = newsclient.ServiceEndpoint{
-		RequestURL: fakes.server.URL,
-		DocsURL:    "http://fake-docs-url",
-	}
 	queried := []TopQueried{
 		{
 			Key:    domains,
@@ -100,19 +91,72 @@ func TestListErrors(t *testing.T) {
 		},
 	}
 	if len(queried) > 0 {
-		topQueried = queried
+		fakes.conf.Cron.Watchlist = watches(queried)
 	}
 	defer teardown()
 
 	r := httptest.NewRequest("GET", "/test", nil)
-	got, err := List(context.Background(), fakes.store, r)
+	got, err := List(context.Background(), fakes.app, r)
 	if err == nil {
 		desc := "returns an error when API_KEY is missing"
 		t.Errorf("%s: List(_, _, _): want (_, error), got (%v, %v)", desc, got, err)
 	}
 }
 
+func TestListRecordsRun(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc          string
+		isServerError bool
+		wantStatus    ingestion.Status
+		wantFetched   int
+	}{
+		{
+			desc:        "records a succeeded run with article counts",
+			wantStatus:  ingestion.Succeeded,
+			wantFetched: len(fakeResponse.Articles),
+		},
+		{
+			desc:          "records a failed run when server errored",
+			isServerError: true,
+			wantStatus:    ingestion.Failed,
+		},
+	}
+
+	for _, test := range tests {
+		fakes, teardown := setup(t, setupOpts{isServerError: test.isServerError})
+		fakes.conf.Cron.Watchlist = []config.Watch{
+			{
+				Key:    string(domains),
+				Values: []string{"some", "valid", "terms"},
+			},
+		}
+		defer teardown()
+
+		repo := &fakerunstore{}
+		r := httptest.NewRequest("GET", "/test", nil)
+		List(context.Background(), fakes.with(repo), r)
+
+		run, err := repo.GetRun(context.Background(), 1)
+		if err != nil {
+			t.Fatalf("%s: List(_, _, _): want a recorded run, got %v", test.desc, err)
+		}
+		if run.Status != test.wantStatus {
+			t.Errorf("%s: List(_, _, _): want run status %v, got %v", test.desc, test.wantStatus, run.Status)
+		}
+		if run.FinishedAt == nil {
+			t.Errorf("%s: List(_, _, _): want a finished run, got %v", test.desc, run)
+		}
+		if len(run.Results) != 1 || run.ArticlesFetched != test.wantFetched || run.ArticlesPersisted != test.wantFetched {
+			t.Errorf("%s: List(_, _, _): want 1 result with %d articles, got %+v", test.desc, test.wantFetched, run)
+		}
+	}
+}
+
 func TestFetchAndPersist(t *testing.T) {
+	t.Parallel()
+
 	tests := []struct {
 		desc         string
 		params       *list.Params
@@ -123,7 +167,7 @@ func TestFetchAndPersist(t *testing.T) {
 		{
 			desc: "returns news.Response given list.Params",
 			params: &list.Params{
-				Language: defaultLang,
+				Language: "en",
 				Domains:  "some-domain-1,some-domain-2",
 			},
 			withArticles: true,
@@ -132,7 +176,7 @@ func TestFetchAndPersist(t *testing.T) {
 		{
 			desc: "returns 0 results for news.Response given list.Params",
 			params: &list.Params{
-				Language: defaultLang,
+				Language: "en",
 				Domains:  "some-domain-1,some-domain-2",
 			},
 			wantResponse: &news.Response{
@@ -143,7 +187,7 @@ func TestFetchAndPersist(t *testing.T) {
 		{
 			desc: "persists articles from news.Response given list.Params",
 			params: &list.Params{
-				Language: defaultLang,
+				Language: "en",
 				Domains:  "some-domain-1,some-domain-2",
 			},
 			withArticles: true,
@@ -162,15 +206,29 @@ func TestFetchAndPersist(t *testing.T) {
 					"bloomberg",
 					"Bloomberg",
 				),
+				toStoreRow(
+					123,
+					"some-author-2",
+					"some-title-2",
+					"some-description-2",
+					"some-URL-2",
+					"some-image-url-2",
+					time.Date(2016, time.August, 15, 0, 0, 0, 0, time.UTC),
+				),
+				toStoreRow(
+					123,
+					"financial-times",
+					"Financial Times",
+				),
 			},
 		},
 	}
 
 	for _, test := range tests {
-		fakes, teardown := setup(t, config{
+		fakes, teardown := setup(t, setupOpts{
 			clockNanosec: 123,
 		})
-		client = &fakeclient{
+		fakes.app.List = &fakeclient{
 			withArticles: test.withArticles,
 			serviceEndpoint: serviceEndpoint{
 				RequestURL: fakes.server.URL,
@@ -178,26 +236,28 @@ func TestFetchAndPersist(t *testing.T) {
 		}
 		defer teardown()
 
-		got, err := fetchAndPersist(context.Background(), fakes.store, client, test.params)
+		got, _, err := fetchAndPersist(context.Background(), fakes.app, test.params)
 		if err != nil {
-			t.Errorf("fetchAndPersist(_, _, _, %v): want (%v, nil), got (%v, %v)", test.params, test.wantResponse, got, err)
+			t.Errorf("fetchAndPersist(_, _, %v): want (%v, nil), got (%v, %v)", test.params, test.wantResponse, got, err)
 		}
 
 		if test.wantResponse != nil {
 			if diff := pretty.Compare(got, test.wantResponse); diff != "" {
-				t.Errorf("%s: fetchAndPersist(_, _, _, %v) diff: (-got +want)\n%s", test.desc, test.params, diff)
+				t.Errorf("%s: fetchAndPersist(_, _, %v) diff: (-got +want)\n%s", test.desc, test.params, diff)
 			}
 		}
 
 		if len(test.wantRows) > 0 {
 			if diff := pretty.Compare(fakes.store.rows, test.wantRows); diff != "" {
-				t.Errorf("%s: fetchAndPersist(_, _, _, %v) diff: (-got +want)\n%s", test.desc, test.params, diff)
+				t.Errorf("%s: fetchAndPersist(_, _, %v) diff: (-got +want)\n%s", test.desc, test.params, diff)
 			}
 		}
 	}
 }
 
 func TestFetchAndPersistErrors(t *testing.T) {
+	t.Parallel()
+
 	tests := []struct {
 		desc            string
 		params          *list.Params
@@ -205,6 +265,9 @@ func TestFetchAndPersistErrors(t *testing.T) {
 		isStoreError    bool
 		isAPIKeyMissing bool
 		withArticles    bool
+		// maxRows is the count of rows the store inserts before erroring.
+		maxRows       int
+		wantPersisted int
 	}{
 		{
 			desc:            "returns an error when API_KEY is missing",
@@ -219,29 +282,39 @@ func TestFetchAndPersistErrors(t *testing.T) {
 			isStoreError: true,
 			withArticles: true,
 		},
+		{
+			desc:          "returns an error with the count of articles persisted before store errored",
+			withArticles:  true,
+			maxRows:       2,
+			wantPersisted: 1,
+		},
 	}
 
 	for _, test := range tests {
-		fakes, teardown := setup(t, config{
+		fakes, teardown := setup(t, setupOpts{
 			isStoreError:    test.isStoreError,
 			isAPIKeyMissing: test.isAPIKeyMissing,
 		})
-		client = &fakeclient{
+		fakes.app.List = &fakeclient{
 			isError:      test.isClientError,
 			withArticles: test.withArticles,
 			serviceEndpoint: serviceEndpoint{
 				RequestURL: fakes.server.URL,
 			},
 		}
+		fakes.store.maxRows = test.maxRows
 		defer teardown()
 
 		params := &list.Params{
-			Language: defaultLang,
+			Language: "en",
 			Domains:  "some-domain-1,some-domain-2",
 		}
-		got, err := fetchAndPersist(context.Background(), fakes.store, client, params)
+		got, persisted, err := fetchAndPersist(context.Background(), fakes.app, params)
 		if err == nil {
-			t.Errorf("%s: fetchAndPersist(_, _, _, %v): want (_, error), got (%v, %v)", test.desc, test.params, got, err)
+			t.Errorf("%s: fetchAndPersist(_, _, %v): want (_, _, error), got (%v, %d, %v)", test.desc, test.params, got, persisted, err)
+		}
+		if persisted != test.wantPersisted {
+			t.Errorf("%s: fetchAndPersist(_, _, %v): want (_, %d, _), got (_, %d, _)", test.desc, test.params, test.wantPersisted, persisted)
 		}
 	}
 }
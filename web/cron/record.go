@@ -0,0 +1,90 @@
+package cron
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/riacataquian/news/api/news"
+	"github.com/riacataquian/news/internal/app"
+	"github.com/riacataquian/news/internal/clock"
+	"github.com/riacataquian/news/internal/ingestion"
+	"github.com/riacataquian/news/internal/metrics"
+)
+
+// recorder keeps track of an ingestion.Run and persists it
+// if the app's data repository is an ingestion.Store.
+//
+// Failing to record a run is logged and never fails the job itself.
+type recorder struct {
+	// ctx persists the run, never cancelled so that a cancelled job's run is recorded as finished too.
+	ctx  context.Context
+	runs ingestion.Store
+	run  *ingestion.Run
+	// clock finishes the run, started is its time the run started at, used to compute the elapsed time.
+	clock   clock.Time
+	started time.Time
+}
+
+// newRun returns a running ingestion.Run of job, started now.
+func newRun(job string, trigger ingestion.Trigger, now time.Time) *ingestion.Run {
+	return &ingestion.Run{
+		Job:       job,
+		Trigger:   trigger,
+		Status:    ingestion.Running,
+		StartedAt: now.UTC(),
+	}
+}
+
+// newRecorder returns a recorder for run, persisting it to a's data repository right away if possible.
+func newRecorder(ctx context.Context, a *app.App, run *ingestion.Run) *recorder {
+	rec := &recorder{ctx: context.WithoutCancel(ctx), run: run, clock: a.Clock, started: a.Clock.Now()}
+
+	runs, ok := a.Store.(ingestion.Store)
+	if !ok {
+		return rec
+	}
+
+	if err := runs.CreateRun(ctx, run); err != nil {
+		log.Printf("error recording %s run: %v", run.Job, err)
+		return rec
+	}
+	rec.runs = runs
+	return rec
+}
+
+// add records the outcome of querying top, whose response res had persisted of its articles persisted.
+func (rec *recorder) add(top TopQueried, res *news.Response, persisted int, err error) {
+	result := &ingestion.Result{
+		Key:       string(top.Key),
+		Values:    top.Values,
+		Persisted: persisted,
+	}
+	if res != nil {
+		result.Fetched = len(res.Articles)
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	rec.addResult(result)
+}
+
+// addResult records the outcome of a single query.
+func (rec *recorder) addResult(result *ingestion.Result) {
+	rec.run.Add(result)
+}
+
+// finish marks the run as finished, counts it in the metrics and persists its final state.
+func (rec *recorder) finish(err error) {
+	rec.run.Finish(rec.clock.Now(), err)
+	metrics.CronDuration.Observe(rec.clock.Since(rec.started).Seconds(), rec.run.Job, string(rec.run.Status))
+	metrics.CronArticles.Add(float64(rec.run.ArticlesFetched), rec.run.Job, "fetched")
+	metrics.CronArticles.Add(float64(rec.run.ArticlesPersisted), rec.run.Job, "persisted")
+	if rec.runs == nil {
+		return
+	}
+
+	if err := rec.runs.UpdateRun(rec.ctx, rec.run); err != nil {
+		log.Printf("error recording %s run %d: %v", rec.run.Job, rec.run.ID, err)
+	}
+}
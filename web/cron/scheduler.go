@@ -0,0 +1,138 @@
+package cron
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/riacataquian/news/internal/app"
+	"github.com/riacataquian/news/internal/ingestion"
+	"github.com/riacataquian/news/internal/lock"
+)
+
+// unlockTimeout is how long releasing a job's lock can take.
+const unlockTimeout = 5 * time.Second
+
+// Schedule describes how often to run a job.
+type Schedule struct {
+	// Job is the name of the job to run.
+	Job string
+	// Every is the interval between runs.
+	// Ticks are aligned to multiples of Every since the zero time, i.e., at the top of the hour
+	// for an hourly job, so that replicas tick at the same time.
+	Every time.Duration
+}
+
+// Scheduler runs jobs as per their schedule.
+//
+// Replicas sharing a lock.Locker run a given job at most once per tick:
+// the replica that acquires the job's lock runs it, the others skip the tick.
+type Scheduler struct {
+	app       *app.App
+	locker    lock.Locker
+	schedules []Schedule
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewScheduler returns a new Scheduler for the supplied schedules.
+// Jobs are run with a while holding a lock acquired from locker.
+func NewScheduler(a *app.App, locker lock.Locker, schedules ...Schedule) *Scheduler {
+	return &Scheduler{
+		app:       a,
+		locker:    locker,
+		schedules: schedules,
+		stop:      make(chan struct{}),
+	}
+}
+
+// Start runs each schedule in the background until Stop is called or ctx is done.
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, sched := range s.schedules {
+		s.wg.Add(1)
+		go func(sched Schedule) {
+			defer s.wg.Done()
+			s.loop(ctx, sched)
+		}(sched)
+	}
+}
+
+// Stop stops the schedules and waits for running jobs to finish.
+func (s *Scheduler) Stop() {
+	close(s.stop)
+	s.wg.Wait()
+}
+
+// loop waits for each tick of sched then runs its job.
+func (s *Scheduler) loop(ctx context.Context, sched Schedule) {
+	for {
+		now := s.app.Clock.Now()
+		slot := now.Truncate(sched.Every).Add(sched.Every)
+		t := s.app.Clock.NewTimer(slot.Sub(now))
+
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-s.stop:
+			t.Stop()
+			return
+		case <-t.C():
+			s.tick(ctx, sched, slot)
+		}
+	}
+}
+
+// tick runs sched's job for the tick at slot, unless another holder has its lock
+// or it already ran for slot.
+// It returns true if the job ran.
+func (s *Scheduler) tick(ctx context.Context, sched Schedule, slot time.Time) bool {
+	key := "cron:" + sched.Job
+	l, ok, err := s.locker.TryLock(ctx, key)
+	if err != nil {
+		log.Printf("error acquiring lock %q: %v", key, err)
+		return false
+	}
+	if !ok {
+		return false
+	}
+	// The lock is released even if ctx is done, i.e., on shutdown.
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), unlockTimeout)
+		defer cancel()
+		if err := l.Unlock(ctx); err != nil {
+			log.Printf("error releasing lock %q: %v", key, err)
+		}
+	}()
+
+	// Another replica may have run the job for this tick and released the lock already.
+	if s.ranSince(ctx, sched.Job, slot) {
+		return false
+	}
+
+	run, err := Run(ctx, s.app, sched.Job, Options{Trigger: ingestion.Scheduled})
+	if err != nil {
+		log.Printf("error running %s job: %v", sched.Job, err)
+	}
+	return run != nil
+}
+
+// ranSince reports whether a scheduled run of job was recorded since the supplied time.
+func (s *Scheduler) ranSince(ctx context.Context, job string, since time.Time) bool {
+	runs, ok := s.app.Store.(ingestion.Store)
+	if !ok {
+		return false
+	}
+
+	run, err := runs.LatestRun(ctx, ingestion.Filter{Job: job, Trigger: ingestion.Scheduled})
+	if err == ingestion.ErrRunNotFound {
+		return false
+	}
+	if err != nil {
+		log.Printf("error looking up the latest %s run: %v", job, err)
+		return false
+	}
+	return !run.StartedAt.Before(since.UTC())
+}
@@ -0,0 +1,114 @@
+package cron
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/riacataquian/news/internal/ingestion"
+	"github.com/riacataquian/news/internal/lock"
+)
+
+func TestTick(t *testing.T) {
+	t.Parallel()
+
+	fakes, teardown := setup(t, setupOpts{})
+	fakes.conf.Cron.Watchlist = watches(testWatchlist)
+	defer teardown()
+
+	ctx := context.Background()
+	repo := &fakerunstore{}
+	locker := lock.NewMemory()
+	sched := Schedule{Job: ListJob, Every: time.Hour}
+	slot := time.Date(2016, time.August, 15, 0, 0, 0, 0, time.UTC)
+
+	// Replicas share the data repository and the locker.
+	replica1 := NewScheduler(fakes.with(repo), locker, sched)
+	replica2 := NewScheduler(fakes.with(repo), locker, sched)
+
+	if ran := replica1.tick(ctx, sched, slot); !ran {
+		t.Fatalf("tick(_, %v, %v): want the first replica to run the job", sched, slot)
+	}
+
+	if ran := replica2.tick(ctx, sched, slot); ran {
+		t.Errorf("tick(_, %v, %v): want the second replica to skip the job that ran for the same tick", sched, slot)
+	}
+
+	if len(repo.runs) != 1 {
+		t.Errorf("tick(_, %v, %v): want 1 recorded run, got %d", sched, slot, len(repo.runs))
+	}
+	if run := repo.runs[1]; run.Trigger != ingestion.Scheduled || run.Status != ingestion.Succeeded {
+		t.Errorf("tick(_, %v, %v): want a succeeded scheduled run, got %+v", sched, slot, run)
+	}
+}
+
+func TestTickLocked(t *testing.T) {
+	t.Parallel()
+
+	fakes, teardown := setup(t, setupOpts{})
+	defer teardown()
+
+	ctx := context.Background()
+	repo := &fakerunstore{}
+	locker := lock.NewMemory()
+	sched := Schedule{Job: ListJob, Every: time.Hour}
+	slot := time.Date(2016, time.August, 15, 0, 0, 0, 0, time.UTC)
+
+	// Another replica is running the job.
+	l, _, _ := locker.TryLock(ctx, "cron:"+ListJob)
+	defer l.Unlock(ctx)
+
+	if ran := NewScheduler(fakes.with(repo), locker, sched).tick(ctx, sched, slot); ran {
+		t.Errorf("tick(_, %v, %v): want to skip the job when its lock is held", sched, slot)
+	}
+	if len(repo.runs) != 0 {
+		t.Errorf("tick(_, %v, %v): want no recorded run, got %d", sched, slot, len(repo.runs))
+	}
+}
+
+func TestTickCancelled(t *testing.T) {
+	t.Parallel()
+
+	fakes, teardown := setup(t, setupOpts{})
+	defer teardown()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	locker := &fakelocker{}
+	sched := Schedule{Job: ListJob, Every: time.Hour}
+	slot := time.Date(2016, time.August, 15, 0, 0, 0, 0, time.UTC)
+
+	NewScheduler(fakes.with(&fakerunstore{}), locker, sched).tick(ctx, sched, slot)
+	if !locker.unlocked || locker.unlockErr != nil {
+		t.Errorf("tick(_, %v, %v): want the lock released with a live context once ctx is done, got (%v, %v)", sched, slot, locker.unlocked, locker.unlockErr)
+	}
+}
+
+func TestSchedulerStart(t *testing.T) {
+	t.Parallel()
+
+	fakes, teardown := setup(t, setupOpts{})
+	fakes.conf.Cron.Watchlist = watches(testWatchlist)
+	defer teardown()
+
+	repo := &fakesyncrunstore{finished: make(chan ingestion.Run, 1)}
+	sched := Schedule{Job: ListJob, Every: time.Hour}
+	s := NewScheduler(fakes.with(repo), lock.NewMemory(), sched)
+	s.Start(context.Background())
+
+	// Wait for the scheduler to wait for the next tick then move to it.
+	fakes.clock.BlockUntil(1)
+	fakes.clock.Advance(time.Hour)
+
+	select {
+	case run := <-repo.finished:
+		want := time.Date(2016, time.August, 15, 1, 0, 0, 0, time.UTC)
+		if run.Trigger != ingestion.Scheduled || !run.StartedAt.Equal(want) {
+			t.Errorf("Start(_): want a scheduled run started at %v, got %+v", want, run)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Start(_): want %s job to run on the next tick", ListJob)
+	}
+
+	s.Stop()
+}
@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/riacataquian/news/internal/app"
+	"github.com/riacataquian/news/internal/httperror"
+	"github.com/riacataquian/news/internal/ingestion"
+	"github.com/riacataquian/news/internal/validation"
+	"github.com/riacataquian/news/web/cron"
+
+	"github.com/gorilla/mux"
+)
+
+// This file contains handlers for admin endpoints.
+
+// dateFormat is the expected format of dates in request bodies.
+const dateFormat = "2006-01-02"
+
+var (
+	runCronJob   = cron.Run
+	startCronJob = cron.Start
+)
+
+// runJobParams is the request parameters for running a cron job.
+type runJobParams struct {
+	// Async, when true, starts the job in the background and responds right away.
+	Async bool `schema:"async"`
+}
+
+// runJobBody is the optional request body for running a cron job.
+type runJobBody struct {
+	// Watchlist restricts the run to these entries, i.e., {"key": "domains", "values": ["wsj.com"]}.
+	Watchlist []struct {
+		Key    string   `json:"key"`
+		Values []string `json:"values"`
+	} `json:"watchlist"`
+	// From and To are the dates to backfill news for, i.e., 2018-07-28.
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Budget is the maximum count of requests a backfill run can make.
+	Budget int `json:"budget"`
+}
+
+// RunJob is the HTTP handler for manually running the cron job named in the URL path.
+//
+// The job runs synchronously unless the `async` parameter is set,
+// in which case it responds with 202 and the started run; poll /cron/runs/{id} for its progress.
+// An optional JSON body restricts the run to specific watchlist entries.
+func RunJob(ctx context.Context, a *app.App, r *http.Request) (*SuccessResponse, error) {
+	if r.Method != http.MethodPost {
+		return nil, &httperror.HTTPError{
+			Code:       http.StatusMethodNotAllowed,
+			Message:    fmt.Sprintf("method %s not allowed", r.Method),
+			RequestURL: r.RequestURI,
+		}
+	}
+
+	// Only the URL's query holds params, the body is reserved for the run's options.
+	params := new(runJobParams)
+	if err := validation.Decode(params, r.URL.Query(), r); err != nil {
+		return nil, err
+	}
+
+	opts, err := decodeRunJobBody(r)
+	if err != nil {
+		return nil, &httperror.HTTPError{
+			Code:       http.StatusBadRequest,
+			Message:    err.Error(),
+			RequestURL: r.RequestURI,
+		}
+	}
+
+	name := mux.Vars(r)["job"]
+	if params.Async {
+		run, err := startCronJob(ctx, a, name, opts)
+		if err != nil {
+			return nil, jobError(err, r)
+		}
+
+		return &SuccessResponse{
+			Code:       http.StatusAccepted,
+			RequestURL: r.RequestURI,
+			Count:      1,
+			Page:       1,
+			TotalCount: 1,
+			Data:       run,
+		}, nil
+	}
+
+	// A run that spent its request budget is paused, not failed.
+	run, err := runCronJob(ctx, a, name, opts)
+	if err != nil && err != ingestion.ErrBudgetExhausted {
+		return nil, jobError(err, r)
+	}
+
+	return &SuccessResponse{
+		Code:       http.StatusOK,
+		RequestURL: r.RequestURI,
+		Count:      1,
+		Page:       1,
+		TotalCount: 1,
+		Data:       run,
+	}, nil
+}
+
+// decodeRunJobBody decodes the request's optional body to cron.Options.
+func decodeRunJobBody(r *http.Request) (cron.Options, error) {
+	opts := cron.Options{}
+	if r.Body == nil {
+		return opts, nil
+	}
+
+	body := new(runJobBody)
+	err := json.NewDecoder(r.Body).Decode(body)
+	if err == io.EOF {
+		return opts, nil
+	}
+	if err != nil {
+		return opts, fmt.Errorf("error decoding body: %v", err)
+	}
+
+	for _, w := range body.Watchlist {
+		opts.Watchlist = append(opts.Watchlist, cron.TopQueried{
+			Key:    cron.Key(w.Key),
+			Values: w.Values,
+		})
+	}
+
+	if body.From != "" {
+		if opts.From, err = time.Parse(dateFormat, body.From); err != nil {
+			return opts, fmt.Errorf("invalid from date, expects %s: %v", dateFormat, err)
+		}
+	}
+	if body.To != "" {
+		if opts.To, err = time.Parse(dateFormat, body.To); err != nil {
+			return opts, fmt.Errorf("invalid to date, expects %s: %v", dateFormat, err)
+		}
+	}
+	opts.Budget = body.Budget
+	return opts, nil
+}
+
+// jobError maps errors from running a cron job to an HTTPError.
+func jobError(err error, r *http.Request) error {
+	var code int
+	switch {
+	case err == cron.ErrUnknownJob:
+		code = http.StatusNotFound
+	case errors.Is(err, cron.ErrNotInWatchlist):
+		code = http.StatusBadRequest
+	case err == cron.ErrInvalidBackfillRange:
+		code = http.StatusBadRequest
+	case err == cron.ErrRunsNotRecorded:
+		code = http.StatusNotImplemented
+	default:
+		if v, ok := err.(*httperror.HTTPError); ok {
+			return v
+		}
+		code = http.StatusInternalServerError
+	}
+
+	return &httperror.HTTPError{
+		Code:       code,
+		Message:    err.Error(),
+		RequestURL: r.RequestURI,
+	}
+}
+
+// APIKeyUsage is the HTTP handler for the usage of each newsapi key of the app's pool,
+// shared by the handlers and the cron jobs.
+func APIKeyUsage(ctx context.Context, a *app.App, r *http.Request) (*SuccessResponse, error) {
+	usage := a.APIKeys.Usage()
+	return &SuccessResponse{
+		Code:       http.StatusOK,
+		RequestURL: r.RequestURI,
+		Count:      len(usage),
+		Page:       1,
+		TotalCount: len(usage),
+		Data:       usage,
+	}, nil
+}
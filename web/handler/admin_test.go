@@ -0,0 +1,201 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/kylelemons/godebug/pretty"
+	"github.com/riacataquian/news/internal/app"
+	"github.com/riacataquian/news/internal/auth"
+	"github.com/riacataquian/news/internal/httperror"
+	"github.com/riacataquian/news/internal/ingestion"
+	"github.com/riacataquian/news/web/cron"
+)
+
+var (
+	originalRunCronJob   = runCronJob
+	originalStartCronJob = startCronJob
+)
+
+// fakeJob returns a fake cron.Run or cron.Start that records the options it's called with.
+func fakeJob(status ingestion.Status, err error, gotOpts *cron.Options) func(context.Context, *app.App, string, cron.Options) (*ingestion.Run, error) {
+	return func(_ context.Context, _ *app.App, name string, opts cron.Options) (*ingestion.Run, error) {
+		*gotOpts = opts
+		if err != nil {
+			return nil, err
+		}
+		return &ingestion.Run{ID: 1, Job: name, Trigger: ingestion.Manual, Status: status}, nil
+	}
+}
+
+func TestRunJob(t *testing.T) {
+	tests := []struct {
+		desc          string
+		url           string
+		body          string
+		wantCode      int
+		wantStatus    ingestion.Status
+		wantWatchlist []cron.TopQueried
+	}{
+		{
+			desc:       "runs the job synchronously",
+			url:        "/admin/cron/list/run",
+			wantCode:   http.StatusOK,
+			wantStatus: ingestion.Succeeded,
+		},
+		{
+			desc:       "starts the job in the background",
+			url:        "/admin/cron/list/run?async=true",
+			wantCode:   http.StatusAccepted,
+			wantStatus: ingestion.Running,
+		},
+		{
+			desc:       "restricts the run to the supplied watchlist",
+			url:        "/admin/cron/list/run",
+			body:       `{"watchlist": [{"key": "domains", "values": ["wsj.com"]}]}`,
+			wantCode:   http.StatusOK,
+			wantStatus: ingestion.Succeeded,
+			wantWatchlist: []cron.TopQueried{
+				{Key: cron.Key("domains"), Values: []string{"wsj.com"}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		var gotOpts cron.Options
+		runCronJob = fakeJob(ingestion.Succeeded, nil, &gotOpts)
+		startCronJob = fakeJob(ingestion.Running, nil, &gotOpts)
+		defer func() {
+			runCronJob = originalRunCronJob
+			startCronJob = originalStartCronJob
+		}()
+
+		req := httptest.NewRequest(http.MethodPost, test.url, strings.NewReader(test.body))
+		req = mux.SetURLVars(req, map[string]string{"job": "list"})
+
+		got, err := RunJob(context.Background(), newApp(&fakestore{}), req)
+		if err != nil {
+			t.Fatalf("%s: RunJob(_, _, _): want (_, nil), got (%v, %v)", test.desc, got, err)
+		}
+
+		want := &SuccessResponse{
+			Code:       test.wantCode,
+			RequestURL: test.url,
+			Count:      1,
+			Page:       1,
+			TotalCount: 1,
+			Data:       &ingestion.Run{ID: 1, Job: "list", Trigger: ingestion.Manual, Status: test.wantStatus},
+		}
+		if diff := pretty.Compare(got, want); diff != "" {
+			t.Errorf("%s: RunJob(_, _, _) diff: (-got +want)\n%s", test.desc, diff)
+		}
+
+		if diff := pretty.Compare(gotOpts.Watchlist, test.wantWatchlist); diff != "" {
+			t.Errorf("%s: RunJob(_, _, _) watchlist diff: (-got +want)\n%s", test.desc, diff)
+		}
+	}
+}
+
+func TestRunJobErrors(t *testing.T) {
+	tests := []struct {
+		desc     string
+		method   string
+		url      string
+		body     string
+		jobErr   error
+		wantCode int
+	}{
+		{
+			desc:     "returns an error when method is not POST",
+			method:   http.MethodGet,
+			wantCode: http.StatusMethodNotAllowed,
+		},
+		{
+			desc:     "returns an error when body is malformed",
+			method:   http.MethodPost,
+			body:     `{"watchlist": `,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			desc:     "returns an error when async is not a boolean",
+			method:   http.MethodPost,
+			url:      "/admin/cron/list/run?async=soon",
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			desc:     "returns an error when a parameter is unknown",
+			method:   http.MethodPost,
+			url:      "/admin/cron/list/run?sync=true",
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			desc:     "returns an error when job is unknown",
+			method:   http.MethodPost,
+			jobErr:   cron.ErrUnknownJob,
+			wantCode: http.StatusNotFound,
+		},
+		{
+			desc:     "returns an error when watchlist is not watched",
+			method:   http.MethodPost,
+			jobErr:   fmt.Errorf("key %q: %w", "some-key", cron.ErrNotInWatchlist),
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			desc:     "returns an error when job errored",
+			method:   http.MethodPost,
+			jobErr:   errors.New("some error"),
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		var gotOpts cron.Options
+		runCronJob = fakeJob(ingestion.Failed, test.jobErr, &gotOpts)
+		defer func() {
+			runCronJob = originalRunCronJob
+		}()
+
+		url := test.url
+		if url == "" {
+			url = "/admin/cron/list/run"
+		}
+		req := httptest.NewRequest(test.method, url, strings.NewReader(test.body))
+		req = mux.SetURLVars(req, map[string]string{"job": "list"})
+
+		got, err := RunJob(context.Background(), newApp(&fakestore{}), req)
+		if v, ok := err.(*httperror.HTTPError); !ok || v.Code != test.wantCode {
+			t.Errorf("%s: RunJob(_, _, _): want (nil, HTTPError with code %d), got (%v, %v)", test.desc, test.wantCode, got, err)
+		}
+	}
+}
+
+func TestAPIKeyUsage(t *testing.T) {
+	t.Parallel()
+
+	a := newApp(&fakestore{})
+	a.APIKeys.Next()
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/apikeys", nil)
+	got, err := APIKeyUsage(context.Background(), a, req)
+	if err != nil {
+		t.Fatalf("APIKeyUsage(_, _, _): want (_, nil), got (_, %v)", err)
+	}
+
+	want := &SuccessResponse{
+		Code:       http.StatusOK,
+		RequestURL: "/admin/apikeys",
+		Count:      1,
+		Page:       1,
+		TotalCount: 1,
+		Data:       []auth.KeyUsage{{Key: "test...", Requests: 1}},
+	}
+	if diff := pretty.Compare(got, want); diff != "" {
+		t.Errorf("APIKeyUsage(_, _, _) diff: (-got +want)\n%s", diff)
+	}
+}
@@ -4,8 +4,10 @@ package handler
 import (
 	"context"
 	"net/http"
+	"time"
 
-	"github.com/riacataquian/news/internal/store"
+	"github.com/riacataquian/news/internal/app"
+	"github.com/riacataquian/news/internal/auth"
 )
 
 // SuccessResponse describes a successful HTTP response.
@@ -20,17 +22,47 @@ type SuccessResponse struct {
 	TotalCount int `json:"totalCount"`
 	// Data is the actual response from newsapi.
 	Data interface{} `json:"data"`
+	// Links are the links to the other pages of a paginated response.
+	Links *Links `json:"links,omitempty"`
+	// LastModified is when Data last changed, if known, i.e., the newest article's publishedAt.
+	// It's sent as the Last-Modified header.
+	LastModified time.Time `json:"-"`
 }
 
-// Func describes a function that handles HTTP requests and responses.
-type Func func(context.Context, store.Store, *http.Request) (*SuccessResponse, error)
+// Func describes a function that handles HTTP requests and responses with the app's dependencies.
+type Func func(context.Context, *app.App, *http.Request) (*SuccessResponse, error)
 
-// Routes is the lookup table for URL paths and their matching handlers.
-var Routes = []struct {
-	Path        string
-	HandlerFunc Func
-}{
-	{"/list", List},
-	{"/headlines", TopHeadlines},
-	{"/{*}", NotFound},
+// Route is a URL path and its matching handler.
+//
+// Requests to a route must be authenticated with a client key granted its Scope, unless it's auth.Public.
+// Its successful responses are cached by clients as per its CacheControl, then revalidated with their ETag.
+type Route struct {
+	Path         string
+	HandlerFunc  Func
+	Scope        auth.Scope
+	CacheControl string
 }
+
+// Routes is the lookup table for URL paths and their matching handlers of the API's v1, see Versions.
+var Routes = []Route{
+	{"/list", List, auth.Read, cacheArticles},
+	{"/headlines", TopHeadlines, auth.Read, cacheHeadlines},
+	{"/cron/runs", ListRuns, auth.Read, revalidate},
+	{"/cron/runs/{id}", GetRun, auth.Read, revalidate},
+	{"/admin/cron/{job}/run", RunJob, auth.Admin, NoStore},
+	{"/admin/apikeys", APIKeyUsage, auth.Admin, NoStore},
+	{"/status", Status, auth.Read, NoStore},
+	{"/{*}", NotFound, auth.Public, NoStore},
+}
+
+// Cache-Control of the routes' responses.
+const (
+	// cacheArticles caches the articles of the everything endpoint, which rarely change once published.
+	cacheArticles = "public, max-age=600"
+	// cacheHeadlines briefly caches the top headlines, which change within minutes.
+	cacheHeadlines = "public, max-age=60"
+	// revalidate caches responses that may change anytime, as long as they're revalidated first.
+	revalidate = "no-cache"
+	// NoStore forbids caching, i.e., for admin operations and error responses.
+	NoStore = "no-store"
+)
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/riacataquian/news/internal/httperror"
+)
+
+// This file contains the pagination links of responses.
+
+// Links are the links to the pages of a paginated response, relative to its RequestURL.
+// They're also sent as an RFC 8288 Link header, see Links.Header.
+type Links struct {
+	Self  string `json:"self,omitempty"`
+	First string `json:"first"`
+	// Prev and Next are empty on the first and the last page.
+	Prev string `json:"prev,omitempty"`
+	Next string `json:"next,omitempty"`
+	Last string `json:"last"`
+}
+
+// newLinks returns the Links of the page of requestURL given the page size and the total count of results.
+// If maxResults is positive, only the pages of its first results are linked, i.e., the ones reachable with newsapi's plan.
+func newLinks(requestURL string, page, pageSize, total, maxResults int) *Links {
+	u, err := url.Parse(requestURL)
+	if err != nil || pageSize <= 0 {
+		return nil
+	}
+
+	last := lastPage(pageSize, total, maxResults)
+	at := func(p int) string {
+		q := u.Query()
+		q.Set("page", strconv.Itoa(p))
+		pageURL := *u
+		pageURL.RawQuery = q.Encode()
+		return pageURL.String()
+	}
+
+	links := &Links{
+		Self:  requestURL,
+		First: at(1),
+		Last:  at(last),
+	}
+	if page > 1 {
+		// Pages past the last one link back to it.
+		prev := page - 1
+		if prev > last {
+			prev = last
+		}
+		links.Prev = at(prev)
+	}
+	if page < last {
+		links.Next = at(page + 1)
+	}
+	return links
+}
+
+// lastPage returns the last page of total results, capped to maxResults if positive. There's at least one page.
+func lastPage(pageSize, total, maxResults int) int {
+	if maxResults > 0 && total > maxResults {
+		total = maxResults
+	}
+	last := (total + pageSize - 1) / pageSize
+	if last < 1 {
+		return 1
+	}
+	return last
+}
+
+// checkReachable returns an HTTPError if the page is past the maxResults reachable with newsapi's plan, if positive,
+// so that requests newsapi rejects aren't made.
+func checkReachable(r *http.Request, page, pageSize, maxResults int) error {
+	if maxResults <= 0 || (page-1)*pageSize < maxResults {
+		return nil
+	}
+	return &httperror.HTTPError{
+		Code:       http.StatusBadRequest,
+		Message:    fmt.Sprintf("invalid page, only the first %d results are reachable, that is %d pages of %d", maxResults, lastPage(pageSize, maxResults, 0), pageSize),
+		RequestURL: r.RequestURI,
+	}
+}
+
+// Header returns the links formatted as an RFC 8288 Link header, i.e., </api/list?page=2>; rel="next".
+func (l *Links) Header() string {
+	var links []string
+	for _, link := range []struct{ rel, url string }{
+		{"self", l.Self},
+		{"first", l.First},
+		{"prev", l.Prev},
+		{"next", l.Next},
+		{"last", l.Last},
+	} {
+		if link.url != "" {
+			links = append(links, fmt.Sprintf("<%s>; rel=%q", link.url, link.rel))
+		}
+	}
+	return strings.Join(links, ", ")
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+	"github.com/riacataquian/news/internal/httperror"
+)
+
+func TestNewLinks(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc       string
+		requestURL string
+		page       int
+		pageSize   int
+		total      int
+		maxResults int
+		want       *Links
+	}{
+		{
+			desc:       "links the previous and the next pages, keeping the other parameters",
+			requestURL: "/api/list?query=bitcoin&page=2&pageSize=20",
+			page:       2,
+			pageSize:   20,
+			total:      70,
+			want: &Links{
+				Self:  "/api/list?query=bitcoin&page=2&pageSize=20",
+				First: "/api/list?page=1&pageSize=20&query=bitcoin",
+				Prev:  "/api/list?page=1&pageSize=20&query=bitcoin",
+				Next:  "/api/list?page=3&pageSize=20&query=bitcoin",
+				Last:  "/api/list?page=4&pageSize=20&query=bitcoin",
+			},
+		},
+		{
+			desc:       "links the pages reachable with the plan only",
+			requestURL: "/api/list?query=bitcoin&page=5",
+			page:       5,
+			pageSize:   20,
+			total:      5000,
+			maxResults: 100,
+			want: &Links{
+				Self:  "/api/list?query=bitcoin&page=5",
+				First: "/api/list?page=1&query=bitcoin",
+				Prev:  "/api/list?page=4&query=bitcoin",
+				Last:  "/api/list?page=5&query=bitcoin",
+			},
+		},
+		{
+			desc:       "links a single page without results",
+			requestURL: "/api/cron/runs",
+			page:       1,
+			pageSize:   20,
+			want: &Links{
+				Self:  "/api/cron/runs",
+				First: "/api/cron/runs?page=1",
+				Last:  "/api/cron/runs?page=1",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got := newLinks(test.requestURL, test.page, test.pageSize, test.total, test.maxResults)
+		if diff := pretty.Compare(got, test.want); diff != "" {
+			t.Errorf("%s: newLinks(_, _, _, _, _) diff: (-got +want)\n%s", test.desc, diff)
+		}
+	}
+}
+
+func TestLinksHeader(t *testing.T) {
+	t.Parallel()
+
+	links := &Links{
+		Self:  "/api/list?page=1",
+		First: "/api/list?page=1",
+		Next:  "/api/list?page=2",
+		Last:  "/api/list?page=5",
+	}
+	want := `</api/list?page=1>; rel="self", </api/list?page=1>; rel="first", </api/list?page=2>; rel="next", </api/list?page=5>; rel="last"`
+	if got := links.Header(); got != want {
+		t.Errorf("Header(): want %q, got %q", want, got)
+	}
+}
+
+func TestCheckReachable(t *testing.T) {
+	t.Parallel()
+
+	r := httptest.NewRequest(http.MethodGet, "/api/list?query=bitcoin&page=6", nil)
+	tests := []struct {
+		desc       string
+		page       int
+		maxResults int
+		wantErr    bool
+	}{
+		{desc: "accepts the last reachable page", page: 5, maxResults: 100},
+		{desc: "rejects pages past the reachable results", page: 6, maxResults: 100, wantErr: true},
+		{desc: "accepts any page without maximum", page: 600},
+	}
+
+	for _, test := range tests {
+		err := checkReachable(r, test.page, 20, test.maxResults)
+		if !test.wantErr {
+			if err != nil {
+				t.Errorf("%s: checkReachable(_, %d, _, %d): want nil, got %v", test.desc, test.page, test.maxResults, err)
+			}
+			continue
+		}
+		if v, ok := err.(*httperror.HTTPError); !ok || v.Code != http.StatusBadRequest {
+			t.Errorf("%s: checkReachable(_, %d, _, %d): want a 400 HTTPError, got %v", test.desc, test.page, test.maxResults, err)
+		}
+	}
+}
@@ -2,120 +2,142 @@ package handler
 
 import (
 	"context"
-	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/riacataquian/news/api/news"
-	"github.com/riacataquian/news/internal/auth"
+	"github.com/riacataquian/news/internal/app"
 	"github.com/riacataquian/news/internal/httperror"
 	"github.com/riacataquian/news/internal/newsclient"
 	"github.com/riacataquian/news/internal/newsclient/headlines"
 	"github.com/riacataquian/news/internal/newsclient/list"
-	"github.com/riacataquian/news/internal/store"
-
-	"github.com/gorilla/schema"
+	"github.com/riacataquian/news/internal/validation"
 )
 
 // This file contains handlers for news endpoint.
 
-var (
-	client newsclient.HTTPClient
-
-	defaultDuration = 5 * time.Second
-
-	listEndpoint      = list.ServiceEndpoint
-	headlinesEndpoint = headlines.ServiceEndpoint
-)
+// defaultNewsPageSize is newsapi's page size when none is requested.
+const defaultNewsPageSize = 20
 
 // List is the HTTP handler for news requests to newsapi's everything endpoint.
 //
 // Official docs: https://newsapi.org/docs/endpoints/everything.
-func List(ctx context.Context, _ store.Store, r *http.Request) (*SuccessResponse, error) {
+func List(ctx context.Context, a *app.App, r *http.Request) (*SuccessResponse, error) {
 	r.ParseForm()
 
-	client = newsclient.New(listEndpoint)
 	params := new(list.Params)
-	err := schema.NewDecoder().Decode(params, r.Form)
-	if err != nil {
-		return nil, fmt.Errorf("error decoding params: %v", err)
+	if err := validation.Decode(params, r.Form, r); err != nil {
+		return nil, err
 	}
 
 	if params.Page == 0 {
 		params.Page = 1
 	}
+	pageSize := params.PageSize
+	if pageSize == 0 {
+		pageSize = defaultNewsPageSize
+	}
+	if err := checkReachable(r, params.Page, pageSize, a.Config.NewsAPI.MaxResults); err != nil {
+		return nil, err
+	}
 
 	// Requests to external services should have timeouts.
-	reqCtx, cancel := context.WithTimeout(ctx, defaultDuration)
+	reqCtx, cancel := context.WithTimeout(ctx, a.Config.NewsAPI.Timeout.Duration)
 	defer cancel()
 
-	res, err := fetch(reqCtx, params)
+	res, err := fetch(reqCtx, a, a.List, params)
 	if err != nil {
-		return nil, &httperror.HTTPError{
-			Code:       http.StatusBadRequest,
-			Message:    err.Error(),
-			RequestURL: r.RequestURI,
-			DocsURL:    listEndpoint.DocsURL,
-		}
+		return nil, upstreamError(err, r, list.ServiceEndpoint.DocsURL)
 	}
 
 	return &SuccessResponse{
-		Code:       http.StatusOK,
-		RequestURL: r.RequestURI,
-		Count:      len(res.Articles),
-		Page:       params.Page,
-		TotalCount: res.TotalResults,
-		Data:       res.Articles,
+		Code:         http.StatusOK,
+		RequestURL:   r.RequestURI,
+		Count:        len(res.Articles),
+		Page:         params.Page,
+		TotalCount:   res.TotalResults,
+		Data:         res.Articles,
+		Links:        newLinks(r.RequestURI, params.Page, pageSize, res.TotalResults, a.Config.NewsAPI.MaxResults),
+		LastModified: lastPublished(res.Articles),
 	}, nil
 }
 
 // TopHeadlines is the HTTP handler for news requests to newsapi's top-headlines endpoint.
 //
 // Official docs: https://newsapi.org/docs/endpoints/top-headlines.
-func TopHeadlines(ctx context.Context, _ store.Store, r *http.Request) (*SuccessResponse, error) {
+func TopHeadlines(ctx context.Context, a *app.App, r *http.Request) (*SuccessResponse, error) {
 	r.ParseForm()
 
-	client = newsclient.New(headlinesEndpoint)
 	params := new(headlines.Params)
-	err := schema.NewDecoder().Decode(params, r.Form)
-	if err != nil {
-		return nil, fmt.Errorf("error decoding params: %v", err)
+	if err := validation.Decode(params, r.Form, r); err != nil {
+		return nil, err
 	}
 
 	if params.Page == 0 {
 		params.Page = 1
 	}
+	pageSize := params.PageSize
+	if pageSize == 0 {
+		pageSize = defaultNewsPageSize
+	}
+	if err := checkReachable(r, params.Page, pageSize, a.Config.NewsAPI.MaxResults); err != nil {
+		return nil, err
+	}
 
 	// Requests to external services should have timeouts.
-	reqCtx, cancel := context.WithTimeout(ctx, defaultDuration)
+	reqCtx, cancel := context.WithTimeout(ctx, a.Config.NewsAPI.Timeout.Duration)
 	defer cancel()
 
-	res, err := fetch(reqCtx, params)
+	res, err := fetch(reqCtx, a, a.Headlines, params)
 	if err != nil {
-		return nil, &httperror.HTTPError{
-			Code:       http.StatusBadRequest,
-			Message:    err.Error(),
-			RequestURL: r.RequestURI,
-			DocsURL:    headlinesEndpoint.DocsURL,
-		}
+		return nil, upstreamError(err, r, headlines.ServiceEndpoint.DocsURL)
 	}
 
 	return &SuccessResponse{
-		Code:       http.StatusOK,
-		RequestURL: r.RequestURI,
-		Count:      len(res.Articles),
-		Page:       params.Page,
-		TotalCount: res.TotalResults,
-		Data:       res.Articles,
+		Code:         http.StatusOK,
+		RequestURL:   r.RequestURI,
+		Count:        len(res.Articles),
+		Page:         params.Page,
+		TotalCount:   res.TotalResults,
+		Data:         res.Articles,
+		Links:        newLinks(r.RequestURI, params.Page, pageSize, res.TotalResults, a.Config.NewsAPI.MaxResults),
+		LastModified: lastPublished(res.Articles),
 	}, nil
 }
 
-// fetch performs the request to the client given params.
-func fetch(ctx context.Context, params newsclient.Params) (*news.Response, error) {
-	authKey, err := auth.LookupAPIAuthKey()
-	if err != nil {
-		return nil, err
+// lastPublished returns the publishedAt of the newest of articles, if any.
+func lastPublished(articles []*news.News) time.Time {
+	var last time.Time
+	for _, a := range articles {
+		if a.PublishedAt.After(last) {
+			last = a.PublishedAt
+		}
+	}
+	return last
+}
+
+// upstreamError maps errors from fetching news to an HTTPError, keeping newsapi's error code.
+func upstreamError(err error, r *http.Request, docsURL string) error {
+	e := &httperror.HTTPError{
+		Code:         http.StatusBadRequest,
+		Message:      err.Error(),
+		RequestURL:   r.RequestURI,
+		DocsURL:      docsURL,
+		UpstreamCode: httperror.UpstreamCode(err),
+	}
+	if e.UpstreamCode != "" {
+		e.Type = httperror.TypeUpstream
 	}
+	return e
+}
 
-	return client.Get(ctx, authKey, params)
+// fetch performs the request to client given params, authenticated with a key from a's pool.
+func fetch(ctx context.Context, a *app.App, client newsclient.HTTPClient, params newsclient.Params) (*news.Response, error) {
+	var res *news.Response
+	err := a.APIKeys.Do(func(authKey string) error {
+		var err error
+		res, err = client.Get(ctx, authKey, params)
+		return err
+	})
+	return res, err
 }
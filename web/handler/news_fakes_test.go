@@ -6,11 +6,13 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
-	"os"
 	"testing"
 	"time"
 
 	"github.com/riacataquian/news/api/news"
+	"github.com/riacataquian/news/internal/app"
+	"github.com/riacataquian/news/internal/auth"
+	"github.com/riacataquian/news/internal/config"
 	"github.com/riacataquian/news/internal/newsclient"
 	"github.com/riacataquian/news/internal/store"
 )
@@ -108,56 +110,59 @@ type serviceEndpoint struct {
 
 type teardown func()
 
-// config encapsulates a test's setup configuration.
-type config struct {
+// setupOpts encapsulates a test's setup configuration.
+type setupOpts struct {
 	isServerError bool
 	isClientError bool
 }
 
 type fakestore struct{}
 
-func (f *fakestore) Create(_ string, _ []string, _ ...store.Row) error {
+func (f *fakestore) Create(_ context.Context, _ string, _ []string, _ ...store.Row) error {
 	return nil
 }
 
 // fakes encapsulates a test's fake structures.
 type fakes struct {
+	// app is built from the other fakes, its clients request the fake server.
+	app    *app.App
 	server *httptest.Server
 	store  *fakestore
 	client newsclient.HTTPClient
 }
 
-// setup performs the necessary monkey-patching per test suite
-// then return a teardown function to return back original values of package wide vars.
-func setup(t *testing.T, conf config) (*fakes, teardown) {
-	t.Helper()
+// newApp returns an app as per the default configuration, storing data in repo.
+func newApp(repo store.Store) *app.App {
+	return app.New(config.Default(), repo, auth.NewKeyPool([]string{"test-api-key"}, auth.DefaultCooldown))
+}
 
-	os.Setenv("API_KEY", "test-api-key")
+// setup builds an app out of fakes per test
+// then return a teardown function to stop the fake server.
+func setup(t *testing.T, opts setupOpts) (*fakes, teardown) {
+	t.Helper()
 
-	fakeserver := setupStubServer(t, conf.isServerError)
+	fakeserver := setupStubServer(t, opts.isServerError)
 	fakeclient := &fakeclient{
-		isError: conf.isClientError,
+		isError: opts.isClientError,
 		serviceEndpoint: serviceEndpoint{
 			RequestURL: fakeserver.URL,
 		},
 	}
 	fakestore := &fakestore{}
-	client = fakeclient
+
+	conf := config.Default()
+	conf.NewsAPI.ListURL = fakeserver.URL
+	conf.NewsAPI.HeadlinesURL = fakeserver.URL
 
 	fakes := fakes{
+		app:    app.New(conf, fakestore, auth.NewKeyPool([]string{"test-api-key"}, auth.DefaultCooldown)),
 		server: fakeserver,
 		store:  fakestore,
 		client: fakeclient,
 	}
 
 	teardown := func() {
-		os.Clearenv()
 		fakeserver.Close()
-
-		client = originalClient
-
-		headlinesEndpoint = originalHeadlinesEndpoint
-		listEndpoint = originalListEndpoint
 	}
 
 	return &fakes, teardown
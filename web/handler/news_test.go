@@ -7,22 +7,13 @@ import (
 	"testing"
 
 	"github.com/kylelemons/godebug/pretty"
-	"github.com/riacataquian/news/internal/newsclient"
-)
-
-var (
-	originalClient            = client
-	originalDefaultDuration   = defaultDuration
-	originalHeadlinesEndpoint = headlinesEndpoint
-	originalListEndpoint      = listEndpoint
+	"github.com/riacataquian/news/internal/httperror"
 )
 
 func TestList(t *testing.T) {
-	fakes, teardown := setup(t, config{})
-	listEndpoint = newsclient.ServiceEndpoint{
-		RequestURL: fakes.server.URL,
-		DocsURL:    "http://fake-docs-url",
-	}
+	t.Parallel()
+
+	fakes, teardown := setup(t, setupOpts{})
 	defer teardown()
 
 	req, err := http.NewRequest(http.MethodGet, fakes.server.URL, nil)
@@ -32,14 +23,16 @@ func TestList(t *testing.T) {
 	}
 
 	want := &SuccessResponse{
-		Code:       http.StatusOK,
-		Count:      len(fakeResponse.Articles),
-		Page:       2,
-		TotalCount: fakeResponse.TotalResults,
-		Data:       fakeResponse.Articles,
+		Code:         http.StatusOK,
+		Count:        len(fakeResponse.Articles),
+		Page:         2,
+		TotalCount:   fakeResponse.TotalResults,
+		Data:         fakeResponse.Articles,
+		Links:        &Links{First: "?page=1", Prev: "?page=1", Last: "?page=1"},
+		LastModified: fakeResponse.Articles[0].PublishedAt,
 	}
 	desc := "returns the list of news given query parameter"
-	got, err := List(context.Background(), fakes.store, req)
+	got, err := List(context.Background(), fakes.app, req)
 	if err != nil {
 		t.Fatalf("%s: List(_, _, _): want(%v, nil), got (%v, %v)", desc, want, got, err)
 	}
@@ -50,6 +43,8 @@ func TestList(t *testing.T) {
 }
 
 func TestListErrors(t *testing.T) {
+	t.Parallel()
+
 	tests := []struct {
 		desc          string
 		isServerError bool
@@ -64,14 +59,14 @@ func TestListErrors(t *testing.T) {
 			desc:   "returns an error when encoding params errored",
 			params: url.Values{"unrecognized-key": {"unrecognized-value"}},
 		},
+		{
+			desc:   "returns an error when the page is past the plan's maximum results",
+			params: url.Values{"query": {"valid-query"}, "page": {"6"}},
+		},
 	}
 
 	for _, test := range tests {
-		fakes, teardown := setup(t, config{isServerError: test.isServerError})
-		listEndpoint = newsclient.ServiceEndpoint{
-			RequestURL: fakes.server.URL,
-			DocsURL:    "http://fake-docs-url",
-		}
+		fakes, teardown := setup(t, setupOpts{isServerError: test.isServerError})
 		defer teardown()
 
 		req, err := http.NewRequest(http.MethodGet, fakes.server.URL, nil)
@@ -80,18 +75,57 @@ func TestListErrors(t *testing.T) {
 			t.Fatalf("List(_, _, _): got error: %v, want nil error", err)
 		}
 
-		if got, err := List(context.Background(), fakes.store, req); err == nil {
+		if got, err := List(context.Background(), fakes.app, req); err == nil {
 			t.Errorf("%s: List(_, _, _), expecting (nil, error), got (%v, %v)", test.desc, got, err)
 		}
 	}
 }
 
-func TestTopHeadlines(t *testing.T) {
-	fakes, teardown := setup(t, config{})
-	headlinesEndpoint = newsclient.ServiceEndpoint{
-		RequestURL: fakes.server.URL,
-		DocsURL:    "http://fake-docs-url",
+func TestListValidationErrors(t *testing.T) {
+	t.Parallel()
+
+	fakes, teardown := setup(t, setupOpts{})
+	defer teardown()
+
+	req, err := http.NewRequest(http.MethodGet, fakes.server.URL, nil)
+	req.Form = url.Values{"query": {"bitcoin"}, "pageSize": {"1000"}, "unrecognized-key": {"unrecognized-value"}}
+	if err != nil {
+		t.Fatalf("List(_, _, _): got error: %v, want nil error", err)
+	}
+
+	desc := "returns the problems of each invalid parameter"
+	got, err := List(context.Background(), fakes.app, req)
+	httpErr, ok := err.(*httperror.HTTPError)
+	if !ok || httpErr.Code != http.StatusUnprocessableEntity || len(httpErr.FieldErrors) != 1 {
+		t.Fatalf("%s: List(_, _, _): want (nil, 422 HTTPError), got (%v, %v)", desc, got, err)
 	}
+
+	// Unknown parameters fail decoding, the others aren't validated until they're fixed.
+	want := []httperror.FieldErr{
+		{Field: "unrecognized-key", Errors: []string{"unknown parameter"}},
+	}
+	if diff := pretty.Compare(httpErr.FieldErrors[0].Errors, want); diff != "" {
+		t.Errorf("%s: List(_, _, _) diff: (-got +want)\n%s", desc, diff)
+	}
+
+	req.Form = url.Values{"query": {"bitcoin"}, "pageSize": {"1000"}}
+	_, err = List(context.Background(), fakes.app, req)
+	httpErr, ok = err.(*httperror.HTTPError)
+	if !ok || len(httpErr.FieldErrors) != 1 {
+		t.Fatalf("%s: List(_, _, _): want (nil, 422 HTTPError), got (_, %v)", desc, err)
+	}
+	want = []httperror.FieldErr{
+		{Field: "pageSize", Errors: []string{"must be between 1 and 100"}},
+	}
+	if diff := pretty.Compare(httpErr.FieldErrors[0].Errors, want); diff != "" {
+		t.Errorf("%s: List(_, _, _) diff: (-got +want)\n%s", desc, diff)
+	}
+}
+
+func TestTopHeadlines(t *testing.T) {
+	t.Parallel()
+
+	fakes, teardown := setup(t, setupOpts{})
 	defer teardown()
 
 	req, err := http.NewRequest(http.MethodGet, fakes.server.URL, nil)
@@ -101,14 +135,16 @@ func TestTopHeadlines(t *testing.T) {
 	}
 
 	want := &SuccessResponse{
-		Code:       http.StatusOK,
-		Count:      len(fakeResponse.Articles),
-		Page:       1,
-		TotalCount: fakeResponse.TotalResults,
-		Data:       fakeResponse.Articles,
+		Code:         http.StatusOK,
+		Count:        len(fakeResponse.Articles),
+		Page:         1,
+		TotalCount:   fakeResponse.TotalResults,
+		Data:         fakeResponse.Articles,
+		Links:        &Links{First: "?page=1", Last: "?page=1"},
+		LastModified: fakeResponse.Articles[0].PublishedAt,
 	}
 	desc := "returns the top headlines news given query parameter"
-	got, err := TopHeadlines(context.Background(), fakes.store, req)
+	got, err := TopHeadlines(context.Background(), fakes.app, req)
 	if err != nil {
 		t.Fatalf("%s: TopHeadlines(_, _, _): want(%v, nil), got (%v, %v)", desc, want, got, err)
 	}
@@ -119,6 +155,8 @@ func TestTopHeadlines(t *testing.T) {
 }
 
 func TestTopHeadlinesErrors(t *testing.T) {
+	t.Parallel()
+
 	tests := []struct {
 		desc          string
 		isServerError bool
@@ -136,11 +174,7 @@ func TestTopHeadlinesErrors(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		fakes, teardown := setup(t, config{isServerError: test.isServerError})
-		headlinesEndpoint = newsclient.ServiceEndpoint{
-			RequestURL: fakes.server.URL,
-			DocsURL:    "http://fake-docs-url",
-		}
+		fakes, teardown := setup(t, setupOpts{isServerError: test.isServerError})
 		defer teardown()
 
 		req, err := http.NewRequest(http.MethodGet, fakes.server.URL, nil)
@@ -149,30 +183,34 @@ func TestTopHeadlinesErrors(t *testing.T) {
 			t.Fatalf("TopHeadlines(_, _, _): got error: %v, want nil error", err)
 		}
 
-		if got, err := TopHeadlines(context.Background(), fakes.store, req); err == nil {
+		if got, err := TopHeadlines(context.Background(), fakes.app, req); err == nil {
 			t.Errorf("%s: TopHeadlines(_, _, _), expecting (nil, error), got (%v, %v)", test.desc, got, err)
 		}
 	}
 }
 
 func TestFetch(t *testing.T) {
-	_, teardown := setup(t, config{})
+	t.Parallel()
+
+	fakes, teardown := setup(t, setupOpts{})
 	defer teardown()
 
 	desc := "returns a SuccessResponse with correct Code and RequestURL"
 	params := fakeParams{lang: "en"}
 	want := fakeResponse
-	got, err := fetch(context.Background(), params)
+	got, err := fetch(context.Background(), fakes.app, fakes.client, params)
 	if err != nil {
-		t.Fatalf("%s: fetch(_, %v): expecting (%v, nil), got (%v, %v)", desc, params, want, got, err)
+		t.Fatalf("%s: fetch(_, _, _, %v): expecting (%v, nil), got (%v, %v)", desc, params, want, got, err)
 	}
 
 	if diff := pretty.Compare(got, want); diff != "" {
-		t.Errorf("%s: fetch(_, %v), diff: (-got +want)\n%s", desc, params, diff)
+		t.Errorf("%s: fetch(_, _, _, %v), diff: (-got +want)\n%s", desc, params, diff)
 	}
 }
 
 func TestFetchErrors(t *testing.T) {
+	t.Parallel()
+
 	tests := []struct {
 		desc          string
 		params        *fakeParams
@@ -190,11 +228,11 @@ func TestFetchErrors(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		_, teardown := setup(t, config{isClientError: test.isClientError})
+		fakes, teardown := setup(t, setupOpts{isClientError: test.isClientError})
 		defer teardown()
 
-		if got, err := fetch(context.Background(), test.params); err == nil {
-			t.Errorf("%s: fetch(_, %v), expecting (nil, error), got (%v, %v)", test.desc, test.params, got, err)
+		if got, err := fetch(context.Background(), fakes.app, fakes.client, test.params); err == nil {
+			t.Errorf("%s: fetch(_, _, _, %v), expecting (nil, error), got (%v, %v)", test.desc, test.params, got, err)
 		}
 	}
 }
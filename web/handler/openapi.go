@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/riacataquian/news/api/news"
+	"github.com/riacataquian/news/internal/auth"
+	"github.com/riacataquian/news/internal/httperror"
+	"github.com/riacataquian/news/internal/ingestion"
+	"github.com/riacataquian/news/internal/newsclient/headlines"
+	"github.com/riacataquian/news/internal/newsclient/list"
+	"github.com/riacataquian/news/internal/openapi"
+)
+
+// This file contains the OpenAPI specification of the routes.
+
+// operation documents a route in the OpenAPI specification, see OpenAPI.
+type operation struct {
+	method  string
+	id      string
+	summary string
+	// path and query are the path and query parameters, as per their `schema` struct tags.
+	path, query interface{}
+	// body is the JSON body of the requests, if any.
+	body interface{}
+	// data is the SuccessResponse.Data of the responses.
+	data interface{}
+	// codes are the status codes of the successful responses, 200 if none.
+	codes []int
+}
+
+// operations documents the Routes by path. Routes without operation, i.e., /{*}, aren't documented.
+var operations = map[string]operation{
+	"/list": {
+		method:  http.MethodGet,
+		id:      "listNews",
+		summary: "Searches the news articles of newsapi's everything endpoint.",
+		query:   list.Params{},
+		data:    []*news.News{},
+	},
+	"/headlines": {
+		method:  http.MethodGet,
+		id:      "topHeadlines",
+		summary: "Lists the news articles of newsapi's top-headlines endpoint.",
+		query:   headlines.Params{},
+		data:    []*news.News{},
+	},
+	"/cron/runs": {
+		method:  http.MethodGet,
+		id:      "listRuns",
+		summary: "Lists the ingestion runs, most recent first.",
+		query:   runsParams{},
+		data:    []*ingestion.Run{},
+	},
+	"/cron/runs/{id}": {
+		method:  http.MethodGet,
+		id:      "getRun",
+		summary: "Gets an ingestion run.",
+		path: struct {
+			ID int64 `schema:"id"`
+		}{},
+		data: ingestion.Run{},
+	},
+	"/admin/cron/{job}/run": {
+		method:  http.MethodPost,
+		id:      "runJob",
+		summary: "Runs a cron job, in the background if async, restricted to the watchlist entries of the body, if any.",
+		path: struct {
+			Job string `schema:"job"`
+		}{},
+		query: runJobParams{},
+		body:  runJobBody{},
+		data:  ingestion.Run{},
+		codes: []int{http.StatusOK, http.StatusAccepted},
+	},
+	"/admin/apikeys": {
+		method:  http.MethodGet,
+		id:      "apiKeyUsage",
+		summary: "Lists the usage of each newsapi key.",
+		data:    []auth.KeyUsage{},
+	},
+	"/status": {
+		method:  http.MethodGet,
+		id:      "status",
+		summary: "Details the last ingestion, the last failed request to newsapi and the newsapi keys left.",
+		data:    ServerStatus{},
+	},
+}
+
+// OpenAPI returns the OpenAPI document of the routes of v, served under its prefix.
+//
+// Successful responses are documented as a SuccessResponse whose data is the operation's,
+// and errors as an HTTPError, or as RFC 7807 problem details, see httperror.Problem.
+func OpenAPI(v Version) *openapi.Document {
+	g := openapi.NewGenerator()
+	success := g.Schema(SuccessResponse{})
+	errorContent := map[string]openapi.MediaType{
+		"application/json":           {Schema: g.Schema(httperror.HTTPError{})},
+		httperror.ProblemContentType: {Schema: g.Schema(httperror.Problem{})},
+	}
+
+	doc := &openapi.Document{
+		OpenAPI: openapi.Version,
+		Info: openapi.Info{
+			Title:       "News Platform",
+			Description: "Searches news articles of https://newsapi.org and monitors their ingestion.",
+			Version:     v.Name,
+		},
+		Servers:  []openapi.Server{{URL: v.Prefix()}},
+		Paths:    make(map[string]openapi.PathItem),
+		Security: []openapi.SecurityRequirement{{"bearerAuth": {}}},
+	}
+
+	for _, route := range v.Routes {
+		op, ok := operations[route.Path]
+		if !ok {
+			continue
+		}
+
+		o := &openapi.Operation{
+			OperationID: op.id,
+			Summary:     op.summary,
+			Description: fmt.Sprintf("Requires a client key granted the %s scope.", route.Scope),
+			Parameters:  append(g.Parameters("path", op.path), g.Parameters("query", op.query)...),
+			Responses: map[string]*openapi.Response{
+				"default": {Description: "Error.", Content: errorContent},
+			},
+		}
+		if op.body != nil {
+			// The fields of a body are optional, unlike the ones of a response, which are always marshalled.
+			body := g.Schema(op.body)
+			body.Required = nil
+			o.RequestBody = &openapi.RequestBody{
+				Content: map[string]openapi.MediaType{"application/json": {Schema: body}},
+			}
+		}
+
+		codes := op.codes
+		if len(codes) == 0 {
+			codes = []int{http.StatusOK}
+		}
+		for _, code := range codes {
+			o.Responses[fmt.Sprint(code)] = &openapi.Response{
+				Description: http.StatusText(code) + ".",
+				Content: map[string]openapi.MediaType{
+					"application/json": {Schema: &openapi.Schema{AllOf: []*openapi.Schema{
+						success,
+						{Type: "object", Properties: map[string]*openapi.Schema{"data": g.Schema(op.data)}},
+					}}},
+				},
+			}
+		}
+		if route.CacheControl != NoStore {
+			o.Responses[fmt.Sprint(http.StatusNotModified)] = &openapi.Response{
+				Description: "Not Modified, the client's copy matches If-None-Match or If-Modified-Since.",
+			}
+		}
+
+		doc.Paths[route.Path] = openapi.PathItem{strings.ToLower(op.method): o}
+	}
+
+	doc.Components = openapi.Components{
+		Schemas: g.Schemas(),
+		SecuritySchemes: map[string]*openapi.SecurityScheme{
+			"bearerAuth": {
+				Type:        "http",
+				Scheme:      "bearer",
+				Description: "A client key, i.e., nk_..., see cmd/apikey.",
+			},
+		},
+	}
+	return doc
+}
+
+// OpenAPISpec returns the handler of the OpenAPI document of the latest version, as JSON.
+// It isn't authenticated, so that clients can be generated out of it.
+func OpenAPISpec() http.Handler {
+	b, err := json.MarshalIndent(OpenAPI(Versions[len(Versions)-1]), "", "  ")
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if err != nil {
+			http.Error(w, fmt.Sprintf("error marshalling the OpenAPI document: %v", err), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("content-type", "application/json")
+		w.Write(b)
+	})
+}
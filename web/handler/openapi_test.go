@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+// update rewrites the golden OpenAPI document, i.e., go test ./web/handler -run TestOpenAPI -update.
+var update = flag.Bool("update", false, "update the golden OpenAPI document")
+
+// goldenOpenAPI is the OpenAPI document clients are generated out of.
+const goldenOpenAPI = "../../docs/openapi.json"
+
+func TestOpenAPIRoutes(t *testing.T) {
+	t.Parallel()
+
+	routes := make(map[string]bool)
+	for _, route := range Routes {
+		routes[route.Path] = true
+		if _, ok := operations[route.Path]; !ok && route.Path != "/{*}" {
+			t.Errorf("operations: want an operation documenting the route %s, got none", route.Path)
+		}
+	}
+	for path := range operations {
+		if !routes[path] {
+			t.Errorf("operations: want a route for the documented path %s, got none", path)
+		}
+	}
+}
+
+func TestOpenAPI(t *testing.T) {
+	got, err := json.MarshalIndent(OpenAPI(Versions[len(Versions)-1]), "", "  ")
+	if err != nil {
+		t.Fatalf("OpenAPI(_): error marshalling document: %v", err)
+	}
+	got = append(got, '\n')
+
+	if *update {
+		if err := ioutil.WriteFile(goldenOpenAPI, got, 0644); err != nil {
+			t.Fatalf("OpenAPI(_): error updating %s: %v", goldenOpenAPI, err)
+		}
+	}
+
+	want, err := ioutil.ReadFile(goldenOpenAPI)
+	if err != nil {
+		t.Fatalf("OpenAPI(_): error reading %s: %v", goldenOpenAPI, err)
+	}
+	if diff := pretty.Compare(string(got), string(want)); diff != "" {
+		t.Errorf("OpenAPI(_) diff: (-got +want), run go test ./web/handler -run TestOpenAPI -update once intended\n%s", diff)
+	}
+}
+
+func TestOpenAPISpec(t *testing.T) {
+	t.Parallel()
+
+	w := httptest.NewRecorder()
+	OpenAPISpec().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+
+	var doc struct {
+		OpenAPI string                 `json:"openapi"`
+		Paths   map[string]interface{} `json:"paths"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&doc); err != nil {
+		t.Fatalf("OpenAPISpec(): error decoding document: %v", err)
+	}
+	if doc.OpenAPI != "3.0.3" || len(doc.Paths) != len(operations) {
+		t.Errorf("OpenAPISpec(): want an OpenAPI 3.0.3 document of %d paths, got %s of %d paths", len(operations), doc.OpenAPI, len(doc.Paths))
+	}
+}
@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/riacataquian/news/internal/app"
+	"github.com/riacataquian/news/internal/httperror"
+	"github.com/riacataquian/news/internal/ingestion"
+	"github.com/riacataquian/news/internal/store"
+	"github.com/riacataquian/news/internal/validation"
+
+	"github.com/gorilla/mux"
+)
+
+// This file contains handlers for ingestion runs endpoint.
+
+const (
+	// defaultRunsPageSize is the default page size for listing ingestion runs.
+	defaultRunsPageSize = 20
+	// maxRunsPageSize is the maximum page size for listing ingestion runs.
+	maxRunsPageSize = 100
+)
+
+// runsParams is the request parameters for listing ingestion runs.
+type runsParams struct {
+	PageSize int `schema:"pageSize"` // default: 20, maximum: 100
+	Page     int `schema:"page"`
+}
+
+// Validate adds the problems of each invalid parameter to errs.
+//
+// It implements validation.Validator interface.
+func (p *runsParams) Validate(errs *validation.Errors) {
+	if p.PageSize < 0 || p.PageSize > maxRunsPageSize {
+		errs.Add("pageSize", "must be between 1 and %d", maxRunsPageSize)
+	}
+	if p.Page < 0 {
+		errs.Add("page", "must be positive")
+	}
+}
+
+// ListRuns is the HTTP handler for listing ingestion runs, most recent first.
+func ListRuns(ctx context.Context, a *app.App, r *http.Request) (*SuccessResponse, error) {
+	r.ParseForm()
+
+	runs, err := runStore(a.Store, r)
+	if err != nil {
+		return nil, err
+	}
+
+	params := new(runsParams)
+	if err := validation.Decode(params, r.Form, r); err != nil {
+		return nil, err
+	}
+
+	if params.Page == 0 {
+		params.Page = 1
+	}
+	if params.PageSize == 0 {
+		params.PageSize = defaultRunsPageSize
+	}
+
+	res, total, err := runs.ListRuns(ctx, params.PageSize, (params.Page-1)*params.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SuccessResponse{
+		Code:       http.StatusOK,
+		RequestURL: r.RequestURI,
+		Count:      len(res),
+		Page:       params.Page,
+		TotalCount: total,
+		Data:       res,
+		Links:      newLinks(r.RequestURI, params.Page, params.PageSize, total, 0),
+	}, nil
+}
+
+// GetRun is the HTTP handler for inspecting a single ingestion run.
+func GetRun(ctx context.Context, a *app.App, r *http.Request) (*SuccessResponse, error) {
+	runs, err := runStore(a.Store, r)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		return nil, &httperror.HTTPError{
+			Code:       http.StatusBadRequest,
+			Message:    "invalid ingestion run ID",
+			RequestURL: r.RequestURI,
+		}
+	}
+
+	run, err := runs.GetRun(ctx, id)
+	if err == ingestion.ErrRunNotFound {
+		return nil, &httperror.HTTPError{
+			Code:       http.StatusNotFound,
+			Message:    err.Error(),
+			RequestURL: r.RequestURI,
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &SuccessResponse{
+		Code:       http.StatusOK,
+		RequestURL: r.RequestURI,
+		Count:      1,
+		Page:       1,
+		TotalCount: 1,
+		Data:       run,
+	}
+	if run.FinishedAt != nil {
+		resp.LastModified = *run.FinishedAt
+	}
+	return resp, nil
+}
+
+// runStore returns the supplied repo as an ingestion.Store,
+// or an HTTPError if the data repository does not record ingestion runs.
+func runStore(repo store.Store, r *http.Request) (ingestion.Store, error) {
+	runs, ok := repo.(ingestion.Store)
+	if !ok {
+		return nil, &httperror.HTTPError{
+			Code:       http.StatusNotImplemented,
+			Message:    "ingestion runs are not recorded by the data repository",
+			RequestURL: r.RequestURI,
+		}
+	}
+	return runs, nil
+}
@@ -0,0 +1,93 @@
+package handler
+
+// This file contains fake definitions of an ingestion runs store.
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/riacataquian/news/internal/ingestion"
+)
+
+var fakeRuns = []*ingestion.Run{
+	{
+		ID:        2,
+		Job:       "list",
+		Trigger:   ingestion.Scheduled,
+		Status:    ingestion.Failed,
+		StartedAt: time.Date(2016, time.August, 16, 0, 0, 0, 0, time.UTC),
+		Error:     "some error",
+	},
+	{
+		ID:                1,
+		Job:               "list",
+		Trigger:           ingestion.Scheduled,
+		Status:            ingestion.Succeeded,
+		StartedAt:         time.Date(2016, time.August, 15, 0, 0, 0, 0, time.UTC),
+		ArticlesFetched:   2,
+		ArticlesPersisted: 2,
+	},
+}
+
+// fakerunstore is a fakestore that also serves ingestion runs.
+// Its runs are ordered by most recently started.
+type fakerunstore struct {
+	fakestore
+	isError bool
+	runs    []*ingestion.Run
+}
+
+func (f *fakerunstore) CreateRun(_ context.Context, run *ingestion.Run) error {
+	run.ID = int64(len(f.runs) + 1)
+	f.runs = append(f.runs, run)
+	return nil
+}
+
+func (f *fakerunstore) UpdateRun(_ context.Context, _ *ingestion.Run) error {
+	return nil
+}
+
+func (f *fakerunstore) ListRuns(_ context.Context, limit, offset int) ([]*ingestion.Run, int, error) {
+	if f.isError {
+		return nil, 0, errors.New("some store error")
+	}
+
+	if offset >= len(f.runs) {
+		return nil, len(f.runs), nil
+	}
+	end := offset + limit
+	if end > len(f.runs) {
+		end = len(f.runs)
+	}
+	return f.runs[offset:end], len(f.runs), nil
+}
+
+func (f *fakerunstore) GetRun(_ context.Context, id int64) (*ingestion.Run, error) {
+	if f.isError {
+		return nil, errors.New("some store error")
+	}
+
+	for _, run := range f.runs {
+		if run.ID == id {
+			return run, nil
+		}
+	}
+	return nil, ingestion.ErrRunNotFound
+}
+
+func (f *fakerunstore) LatestRun(_ context.Context, filter ingestion.Filter) (*ingestion.Run, error) {
+	if f.isError {
+		return nil, errors.New("some store error")
+	}
+
+	for _, run := range f.runs {
+		if filter.Job != "" && run.Job != filter.Job ||
+			filter.Trigger != "" && run.Trigger != filter.Trigger ||
+			filter.Status != "" && run.Status != filter.Status {
+			continue
+		}
+		return run, nil
+	}
+	return nil, ingestion.ErrRunNotFound
+}
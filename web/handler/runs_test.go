@@ -0,0 +1,213 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/kylelemons/godebug/pretty"
+	"github.com/riacataquian/news/internal/httperror"
+	"github.com/riacataquian/news/internal/store"
+)
+
+func TestListRuns(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc   string
+		params url.Values
+		want   *SuccessResponse
+	}{
+		{
+			desc: "returns the first page of runs",
+			want: &SuccessResponse{
+				Code:       http.StatusOK,
+				RequestURL: "/cron/runs",
+				Count:      len(fakeRuns),
+				Page:       1,
+				TotalCount: len(fakeRuns),
+				Data:       fakeRuns,
+				Links:      &Links{Self: "/cron/runs", First: "/cron/runs?page=1", Last: "/cron/runs?page=1"},
+			},
+		},
+		{
+			desc:   "returns the requested page of runs",
+			params: url.Values{"page": {"2"}, "pageSize": {"1"}},
+			want: &SuccessResponse{
+				Code:       http.StatusOK,
+				RequestURL: "/cron/runs",
+				Count:      1,
+				Page:       2,
+				TotalCount: len(fakeRuns),
+				Data:       fakeRuns[1:],
+				Links:      &Links{Self: "/cron/runs", First: "/cron/runs?page=1", Prev: "/cron/runs?page=1", Last: "/cron/runs?page=2"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		repo := &fakerunstore{runs: fakeRuns}
+		req := httptest.NewRequest(http.MethodGet, "/cron/runs", nil)
+		req.Form = test.params
+
+		got, err := ListRuns(context.Background(), newApp(repo), req)
+		if err != nil {
+			t.Fatalf("%s: ListRuns(_, _, _): want (%v, nil), got (%v, %v)", test.desc, test.want, got, err)
+		}
+
+		if diff := pretty.Compare(got, test.want); diff != "" {
+			t.Errorf("%s: ListRuns(_, _, _) diff: (-got +want)\n%s", test.desc, diff)
+		}
+	}
+}
+
+func TestListRunsErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc     string
+		repo     store.Store
+		params   url.Values
+		wantCode int
+	}{
+		{
+			desc:     "returns an error when the store does not record runs",
+			repo:     &fakestore{},
+			wantCode: http.StatusNotImplemented,
+		},
+		{
+			desc:     "returns an error when page size exceeded the maximum",
+			repo:     &fakerunstore{},
+			params:   url.Values{"pageSize": {"500"}},
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			desc:     "returns an error when page is negative",
+			repo:     &fakerunstore{},
+			params:   url.Values{"page": {"-1"}},
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			desc:     "returns an error when a parameter is unknown",
+			repo:     &fakerunstore{},
+			params:   url.Values{"sortBy": {"startedAt"}},
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			desc: "returns an error when store errored",
+			repo: &fakerunstore{isError: true},
+		},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/cron/runs", nil)
+		req.Form = test.params
+
+		got, err := ListRuns(context.Background(), newApp(test.repo), req)
+		if err == nil {
+			t.Fatalf("%s: ListRuns(_, _, _): want (nil, error), got (%v, %v)", test.desc, got, err)
+		}
+
+		if test.wantCode == 0 {
+			continue
+		}
+		if v, ok := err.(*httperror.HTTPError); !ok || v.Code != test.wantCode {
+			t.Errorf("%s: ListRuns(_, _, _): want HTTPError with code %d, got %v", test.desc, test.wantCode, err)
+		}
+	}
+}
+
+func TestGetRun(t *testing.T) {
+	t.Parallel()
+
+	repo := &fakerunstore{runs: fakeRuns}
+	req := httptest.NewRequest(http.MethodGet, "/cron/runs/1", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "1"})
+
+	want := &SuccessResponse{
+		Code:       http.StatusOK,
+		RequestURL: "/cron/runs/1",
+		Count:      1,
+		Page:       1,
+		TotalCount: 1,
+		Data:       fakeRuns[1],
+	}
+	desc := "returns the run matching the supplied ID"
+	got, err := GetRun(context.Background(), newApp(repo), req)
+	if err != nil {
+		t.Fatalf("%s: GetRun(_, _, _): want (%v, nil), got (%v, %v)", desc, want, got, err)
+	}
+
+	if diff := pretty.Compare(got, want); diff != "" {
+		t.Errorf("%s: GetRun(_, _, _) diff: (-got +want)\n%s", desc, diff)
+	}
+}
+
+func TestGetRunErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc     string
+		id       string
+		isError  bool
+		wantCode int
+	}{
+		{
+			desc:     "returns an error when ID is invalid",
+			id:       "not-an-id",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			desc:     "returns an error when run is not found",
+			id:       "123",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			desc:    "returns an error when store errored",
+			id:      "1",
+			isError: true,
+		},
+	}
+
+	for _, test := range tests {
+		repo := &fakerunstore{runs: fakeRuns, isError: test.isError}
+		req := httptest.NewRequest(http.MethodGet, "/cron/runs/"+test.id, nil)
+		req = mux.SetURLVars(req, map[string]string{"id": test.id})
+
+		got, err := GetRun(context.Background(), newApp(repo), req)
+		if err == nil {
+			t.Fatalf("%s: GetRun(_, _, _): want (nil, error), got (%v, %v)", test.desc, got, err)
+		}
+
+		if test.wantCode == 0 {
+			continue
+		}
+		if v, ok := err.(*httperror.HTTPError); !ok || v.Code != test.wantCode {
+			t.Errorf("%s: GetRun(_, _, _): want HTTPError with code %d, got %v", test.desc, test.wantCode, err)
+		}
+	}
+}
+
+func TestListRunsInvalidParams(t *testing.T) {
+	t.Parallel()
+
+	req := httptest.NewRequest(http.MethodGet, "/cron/runs", nil)
+	req.Form = url.Values{"page": {"abc"}}
+
+	desc := "returns the problems of each invalid parameter"
+	got, err := ListRuns(context.Background(), newApp(&fakerunstore{}), req)
+	httpErr, ok := err.(*httperror.HTTPError)
+	if !ok || httpErr.Code != http.StatusUnprocessableEntity || len(httpErr.FieldErrors) != 1 {
+		t.Fatalf("%s: ListRuns(_, _, _): want (nil, 422 HTTPError), got (%v, %v)", desc, got, err)
+	}
+
+	want := []httperror.FieldErr{
+		{Field: "page", Errors: []string{"must be an integer"}},
+	}
+	if diff := pretty.Compare(httpErr.FieldErrors[0].Errors, want); diff != "" {
+		t.Errorf("%s: ListRuns(_, _, _) diff: (-got +want)\n%s", desc, diff)
+	}
+}
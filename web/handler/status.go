@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/riacataquian/news/internal/app"
+	"github.com/riacataquian/news/internal/auth"
+	"github.com/riacataquian/news/internal/ingestion"
+)
+
+// This file contains the handler for the status endpoint.
+
+// ServerStatus describes the state of the ingestion and of newsapi, i.e., for a status page.
+type ServerStatus struct {
+	// LastIngestion is the most recent successful ingestion run, if runs are recorded.
+	LastIngestion *ingestion.Run `json:"lastIngestion"`
+	// LastUpstreamError is the last failed request to newsapi, if any.
+	LastUpstreamError *auth.UpstreamError `json:"lastUpstreamError"`
+	// APIKeys are the count of newsapi keys per state, the available ones being the quota left.
+	APIKeys auth.KeyCounts `json:"apiKeys"`
+}
+
+// Status is the HTTP handler for the detailed status of the server's dependencies.
+func Status(ctx context.Context, a *app.App, r *http.Request) (*SuccessResponse, error) {
+	status := &ServerStatus{
+		LastUpstreamError: a.APIKeys.LastError(),
+		APIKeys:           a.APIKeys.Counts(),
+	}
+
+	if runs, ok := a.Store.(ingestion.Store); ok {
+		run, err := runs.LatestRun(ctx, ingestion.Filter{Status: ingestion.Succeeded})
+		switch err {
+		case nil:
+			status.LastIngestion = run
+		case ingestion.ErrRunNotFound:
+		default:
+			return nil, err
+		}
+	}
+
+	return &SuccessResponse{
+		Code:       http.StatusOK,
+		RequestURL: r.RequestURI,
+		Count:      1,
+		Page:       1,
+		TotalCount: 1,
+		Data:       status,
+	}, nil
+}
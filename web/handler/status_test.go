@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+	"github.com/riacataquian/news/internal/auth"
+	"github.com/riacataquian/news/internal/store"
+)
+
+func TestStatus(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc string
+		repo store.Store
+		want *ServerStatus
+	}{
+		{
+			desc: "returns the last successful ingestion",
+			repo: &fakerunstore{runs: fakeRuns},
+			want: &ServerStatus{LastIngestion: fakeRuns[1], APIKeys: auth.KeyCounts{Available: 1}},
+		},
+		{
+			desc: "omits the last ingestion when runs aren't recorded",
+			repo: &fakestore{},
+			want: &ServerStatus{APIKeys: auth.KeyCounts{Available: 1}},
+		},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/api/status", nil)
+		got, err := Status(context.Background(), newApp(test.repo), req)
+		if err != nil {
+			t.Fatalf("%s: Status(_, _, _): want (_, nil), got (_, %v)", test.desc, err)
+		}
+
+		want := &SuccessResponse{
+			Code:       http.StatusOK,
+			RequestURL: "/api/status",
+			Count:      1,
+			Page:       1,
+			TotalCount: 1,
+			Data:       test.want,
+		}
+		if diff := pretty.Compare(got, want); diff != "" {
+			t.Errorf("%s: Status(_, _, _) diff: (-got +want)\n%s", test.desc, diff)
+		}
+	}
+}
+
+func TestStatusErrors(t *testing.T) {
+	t.Parallel()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/status", nil)
+	if got, err := Status(context.Background(), newApp(&fakerunstore{isError: true}), req); err == nil {
+		t.Errorf("Status(_, _, _): want (nil, error) when the store errored, got (%v, nil)", got)
+	}
+}
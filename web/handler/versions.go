@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// This file contains the versions of the API and their deprecation.
+
+// Version is a version of the API, its Routes mounted under its Prefix.
+//
+// Changes that break consumers, i.e., evolving the SuccessResponse envelope with cursors, take a new version
+// along with the Deprecation of the previous one, so that its consumers keep working until its sunset.
+type Version struct {
+	// Name is the version's path segment, i.e., v1.
+	Name   string
+	Routes []Route
+	// Deprecation is set once the version is deprecated, nil otherwise.
+	Deprecation *Deprecation
+}
+
+// Versions are the versions of the API, oldest first.
+var Versions = []Version{
+	{Name: "v1", Routes: Routes},
+}
+
+// unversionedSince is when /api was deprecated in favor of /api/v1.
+var unversionedSince = time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC)
+
+// Unversioned returns the routes served under /api, which predate versioning: an alias of v1,
+// deprecated in its favor, sunset at sunset if not zero.
+func Unversioned(sunset time.Time) Version {
+	return Version{
+		Routes: Routes,
+		Deprecation: &Deprecation{
+			Since:     unversionedSince,
+			Sunset:    sunset,
+			Successor: "/api/v1",
+		},
+	}
+}
+
+// Prefix returns the path prefix of the version's routes: /api/<name>, or /api for the unversioned routes.
+func (v Version) Prefix() string {
+	if v.Name == "" {
+		return "/api"
+	}
+	return "/api/" + v.Name
+}
+
+// Deprecation describes when a version was deprecated, when it's sunset, and what replaces it.
+type Deprecation struct {
+	Since time.Time
+	// Sunset is when the version stops being served, zero until it's planned.
+	Sunset time.Time
+	// Successor is the path prefix of the version replacing it, i.e., /api/v1.
+	Successor string
+}
+
+// Middleware wraps h, i.e., as a mux.MiddlewareFunc, to announce the version's deprecation, if any, in the responses:
+// with a Deprecation header, see RFC 9745, a Sunset header once planned, see RFC 8594,
+// and a Link header to the same route of the successor version.
+func (v Version) Middleware(h http.Handler) http.Handler {
+	d := v.Deprecation
+	if d == nil {
+		return h
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", fmt.Sprintf("@%d", d.Since.Unix()))
+		if !d.Sunset.IsZero() {
+			w.Header().Set("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+		}
+		if d.Successor != "" {
+			successor := d.Successor + strings.TrimPrefix(r.URL.Path, v.Prefix())
+			w.Header().Add("Link", fmt.Sprintf("<%s>; rel=%q", successor, "successor-version"))
+		}
+		h.ServeHTTP(w, r)
+	})
+}
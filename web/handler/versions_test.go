@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestVersionMiddleware(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc        string
+		version     Version
+		path        string
+		wantPrefix  string
+		wantHeaders http.Header
+	}{
+		{
+			desc:        "doesn't announce anything for current versions",
+			version:     Versions[0],
+			path:        "/api/v1/list",
+			wantPrefix:  "/api/v1",
+			wantHeaders: http.Header{},
+		},
+		{
+			desc:       "announces the deprecation of the unversioned routes and links their successor",
+			version:    Unversioned(time.Time{}),
+			path:       "/api/cron/runs/1",
+			wantPrefix: "/api",
+			wantHeaders: http.Header{
+				"Deprecation": {"@1792281600"},
+				"Link":        {`</api/v1/cron/runs/1>; rel="successor-version"`},
+			},
+		},
+		{
+			desc:       "announces the sunset once planned",
+			version:    Unversioned(time.Date(2027, time.April, 18, 0, 0, 0, 0, time.UTC)),
+			path:       "/api/list",
+			wantPrefix: "/api",
+			wantHeaders: http.Header{
+				"Deprecation": {"@1792281600"},
+				"Sunset":      {"Sun, 18 Apr 2027 00:00:00 GMT"},
+				"Link":        {`</api/v1/list>; rel="successor-version"`},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		if got := test.version.Prefix(); got != test.wantPrefix {
+			t.Errorf("%s: Prefix(): want %q, got %q", test.desc, test.wantPrefix, got)
+		}
+
+		w := httptest.NewRecorder()
+		h := test.version.Middleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.path, nil))
+		if diff := pretty.Compare(w.Header(), test.wantHeaders); diff != "" {
+			t.Errorf("%s: Middleware(_) diff: (-got +want)\n%s", test.desc, diff)
+		}
+	}
+}